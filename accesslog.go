@@ -0,0 +1,241 @@
+package libgemini
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessLogFormat selects how NewAccessLogger formats entries.
+type AccessLogFormat int
+
+const (
+	// AccessLogJSON writes one JSON object per request, like the loggers
+	// returned by NewLoggerFromPath.
+	AccessLogJSON AccessLogFormat = iota
+
+	// AccessLogCommon writes lines in the style of the Common Log Format:
+	//
+	//	203.0.113.7 - SHA256:AB12... [19/Oct/2026:13:55:36 +0000] "gemini://example.com/" 20 1234 "text/gemini" "example.com" 3ms
+	//
+	// The client certificate fingerprint takes the place of the user, and
+	// the meta, SNI and duration are appended.
+	AccessLogCommon
+)
+
+// Attribute keys of access log entries.
+const (
+	AccessKeyRemoteAddr  = "remote_addr"
+	AccessKeySNI         = "sni"
+	AccessKeyURL         = "url"
+	AccessKeyStatus      = "status"
+	AccessKeyMeta        = "meta"
+	AccessKeyBytes       = "bytes"
+	AccessKeyDuration    = "duration"
+	AccessKeyFingerprint = "client_fingerprint"
+	accessLogMsg         = "request"
+	commonLogTimeLayout  = "02/Jan/2006:15:04:05 -0700"
+)
+
+// NewAccessLogger returns a logger suitable for AccessLog, writing to fpath
+// using the same conventions as NewLoggerFromPath: an empty path discards
+// entries, and :stdout: or :stderr: write to StdOut or StdErr. Unlike
+// NewLoggerFromPath, files are appended to. The file is closed when ctx is
+// done.
+func NewAccessLogger(ctx context.Context, fpath string, format AccessLogFormat) (*slog.Logger, error) {
+	if fpath == "" {
+		return slog.New(NoopHandler{}), nil
+	}
+
+	wc, err := openLogOutput(fpath, appendFileFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	closeOnDone(ctx, wc)
+
+	if format == AccessLogCommon {
+		return slog.New(NewCommonLogHandler(wc)), nil
+	}
+
+	return slog.New(slog.NewJSONHandler(wc, nil)), nil
+}
+
+// AccessLog returns middleware logging every request to logger once it has
+// been handled, with its remote address, SNI, URL, response header, body
+// size, duration and client certificate fingerprint. Nothing is logged if
+// logger is nil.
+func AccessLog(logger *slog.Logger) Middleware {
+	logger = loggerOrNoop(logger)
+
+	return func(h Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			start := time.Now()
+			rec := &statusWriter{ResponseWriter: w}
+
+			h.ServeGemini(rec, r)
+
+			status, meta := rec.header()
+
+			sni := ""
+			if r.TLS != nil {
+				sni = r.TLS.ServerName
+			}
+
+			logger.LogAttrs(
+				r.Context(), slog.LevelInfo, accessLogMsg,
+				slog.String(AccessKeyRemoteAddr, r.RemoteAddr),
+				slog.String(AccessKeySNI, sni),
+				slog.String(AccessKeyURL, r.u.String()),
+				slog.Int(AccessKeyStatus, int(status)),
+				slog.String(AccessKeyMeta, meta),
+				slog.Int64(AccessKeyBytes, rec.bytes),
+				slog.Duration(AccessKeyDuration, time.Since(start)),
+				slog.String(AccessKeyFingerprint, r.ClientFingerprint()),
+			)
+		})
+	}
+}
+
+// statusWriter records the header and body size written through it.
+type statusWriter struct {
+	ResponseWriter
+	status      StatusCode
+	meta        string
+	wroteHeader bool
+	bytes       int64
+}
+
+func (sw *statusWriter) WriteHeader(status StatusCode, meta string) {
+	if !sw.wroteHeader {
+		sw.status, sw.meta, sw.wroteHeader = status, meta, true
+	}
+
+	sw.ResponseWriter.WriteHeader(status, meta)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.WriteHeader(Success, DefaultMIME)
+	}
+
+	n, err := sw.ResponseWriter.Write(p)
+	sw.bytes += int64(n)
+
+	return n, err //nolint:wrapcheck
+}
+
+// header returns the header sent to the client, accounting for the
+// implicit header and for invalid headers being replaced by the server.
+func (sw *statusWriter) header() (StatusCode, string) {
+	if !sw.wroteHeader {
+		return Success, DefaultMIME
+	}
+
+	if validHeader(sw.status, sw.meta) != nil {
+		return TemporaryFailure, "internal server error"
+	}
+
+	return sw.status, sw.meta
+}
+
+// CommonLogHandler is a slog.Handler writing access log entries produced
+// by AccessLog in the AccessLogCommon format. Other records are written
+// as their message followed by key=value pairs.
+type CommonLogHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	attrs []slog.Attr
+}
+
+// NewCommonLogHandler returns a CommonLogHandler writing to w.
+func NewCommonLogHandler(w io.Writer) *CommonLogHandler {
+	return &CommonLogHandler{mu: &sync.Mutex{}, w: w}
+}
+
+func (h *CommonLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (h *CommonLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &CommonLogHandler{mu: h.mu, w: h.w, attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+// WithGroup returns h unchanged: groups are not supported.
+func (h *CommonLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func (h *CommonLogHandler) Handle(_ context.Context, record slog.Record) error {
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+
+		return true
+	})
+
+	var line string
+	if record.Message == accessLogMsg {
+		line = commonLogLine(record.Time, attrs)
+	} else {
+		line = plainLogLine(record.Message, attrs)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := io.WriteString(h.w, line+"\n"); err != nil {
+		return fmt.Errorf("could not write log entry: %w", err)
+	}
+
+	return nil
+}
+
+func commonLogLine(t time.Time, attrs []slog.Attr) string {
+	vals := make(map[string]slog.Value, len(attrs))
+	for _, attr := range attrs {
+		vals[attr.Key] = attr.Value.Resolve()
+	}
+
+	field := func(key string) string {
+		val, ok := vals[key]
+		if !ok || val.String() == "" {
+			return "-"
+		}
+
+		return val.String()
+	}
+
+	host := field(AccessKeyRemoteAddr)
+	if i := strings.LastIndex(host, ":"); i > 0 {
+		host = strings.Trim(host[:i], "[]")
+	}
+
+	duration := field(AccessKeyDuration)
+	if val, ok := vals[AccessKeyDuration]; ok && val.Kind() == slog.KindDuration {
+		duration = strconv.FormatInt(val.Duration().Milliseconds(), 10) + "ms"
+	}
+
+	return fmt.Sprintf(
+		"%s - %s [%s] %q %s %s %q %q %s",
+		host, field(AccessKeyFingerprint), t.Format(commonLogTimeLayout),
+		field(AccessKeyURL), field(AccessKeyStatus), field(AccessKeyBytes),
+		field(AccessKeyMeta), field(AccessKeySNI), duration,
+	)
+}
+
+func plainLogLine(msg string, attrs []slog.Attr) string {
+	bdr := &strings.Builder{}
+	bdr.WriteString(msg)
+
+	for _, attr := range attrs {
+		fmt.Fprintf(bdr, " %s=%q", attr.Key, attr.Value.Resolve().String())
+	}
+
+	return bdr.String()
+}
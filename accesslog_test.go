@@ -0,0 +1,127 @@
+package libgemini
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func accessLogTestHandler(w ResponseWriter, r *Request) {
+	switch r.URL().Path {
+	case "/hello":
+		w.Write([]byte("hello"))
+	case "/implicit":
+	case "/invalid":
+		w.WriteHeader(Success, strings.Repeat("x", maxMetaSize+1))
+	default:
+		Error(w, NotFound, "not found")
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := AccessLog(slog.New(slog.NewJSONHandler(buf, nil)))(HandlerFunc(accessLogTestHandler))
+
+	cases := []struct {
+		path   string
+		status StatusCode
+		meta   string
+		bytes  int
+	}{
+		{"/hello", Success, DefaultMIME, len("hello")},
+		{"/implicit", Success, DefaultMIME, 0},
+		{"/invalid", TemporaryFailure, "internal server error", 0},
+		{"/missing", NotFound, "not found", 0},
+	}
+
+	for _, c := range cases {
+		buf.Reset()
+
+		req := mustRequest(t, "gemini://example.com"+c.path)
+		req.RemoteAddr = "192.0.2.1:4242"
+		req.TLS = &tls.ConnectionState{ServerName: "example.com"}
+
+		h.ServeGemini(&recorder{}, req)
+
+		entry := make(map[string]any)
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: could not parse entry '%s': %v", c.path, buf.String(), err)
+		}
+
+		checks := map[string]any{
+			AccessKeyRemoteAddr: "192.0.2.1:4242",
+			AccessKeySNI:        "example.com",
+			AccessKeyURL:        "gemini://example.com" + c.path,
+			AccessKeyStatus:     float64(c.status),
+			AccessKeyMeta:       c.meta,
+			AccessKeyBytes:      float64(c.bytes),
+		}
+
+		for key, want := range checks {
+			if entry[key] != want {
+				t.Errorf("%s: %s is %v, want %v", c.path, key, entry[key], want)
+			}
+		}
+
+		if _, ok := entry[AccessKeyDuration]; !ok {
+			t.Errorf("%s: missing duration", c.path)
+		}
+	}
+}
+
+func TestAccessLogNilLogger(t *testing.T) {
+	rec := &recorder{}
+	AccessLog(nil)(HandlerFunc(accessLogTestHandler)).ServeGemini(rec, mustRequest(t, "gemini://example.com/hello"))
+
+	if rec.status != Success || rec.body.String() != "hello" {
+		t.Errorf("got '%d %s' '%s'", rec.status, rec.meta, rec.body.String())
+	}
+}
+
+func TestCommonLogHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := AccessLog(slog.New(NewCommonLogHandler(buf)))(HandlerFunc(accessLogTestHandler))
+
+	req := mustRequest(t, "gemini://example.com/hello")
+	req.RemoteAddr = "[2001:db8::1]:4242"
+
+	h.ServeGemini(&recorder{}, req)
+
+	re := regexp.MustCompile(`^2001:db8::1 - - \[[^\]]+\] "gemini://example.com/hello" 20 5 "text/gemini" "-" \d+ms\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("unexpected line: %s", buf.String())
+	}
+}
+
+func TestNewAccessLogger(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "access.log")
+	h := HandlerFunc(accessLogTestHandler)
+
+	for range 2 {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		logger, err := NewAccessLogger(ctx, fpath, AccessLogCommon)
+		if err != nil {
+			t.Fatalf("could not create logger: %v", err)
+		}
+
+		AccessLog(logger)(h).ServeGemini(&recorder{}, mustRequest(t, "gemini://example.com/missing"))
+		cancel()
+	}
+
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		t.Fatalf("could not read log: %v", err)
+	}
+
+	if n := strings.Count(string(data), `"gemini://example.com/missing" 51 0`); n != 2 {
+		t.Errorf("expected 2 entries, got %d: %s", n, data)
+	}
+}
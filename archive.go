@@ -0,0 +1,280 @@
+package libgemini
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var ErrUnknownArchive = errors.New("unknown archive format")
+
+const DefaultArchiveReloadInterval = 10 * time.Second
+
+// OpenArchive loads the archive at fpath into memory and returns its
+// contents as an fs.FS, suitable for FileServer. The format is inferred
+// from the extension: .tar, .tar.gz, .tgz or .zip.
+//
+// If the files are stored under a top-level directory, use fs.Sub to
+// serve its contents.
+func OpenArchive(fpath string) (fs.FS, error) {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read archive '%s': %w", fpath, err)
+	}
+
+	lower := strings.ToLower(fpath)
+
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ZipFS(data)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gzr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("could not read gzip archive '%s': %w", fpath, err)
+		}
+		defer gzr.Close()
+
+		return TarFS(gzr)
+	case strings.HasSuffix(lower, ".tar"):
+		return TarFS(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownArchive, fpath)
+	}
+}
+
+// ZipFS returns the contents of the zip archive in data as an fs.FS.
+func ZipFS(data []byte) (fs.FS, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("could not read zip archive: %w", err)
+	}
+
+	return zr, nil
+}
+
+// TarFS reads the tar archive from r into memory and returns its contents
+// as an fs.FS. Only regular files and directories are kept; links and
+// entries escaping the archive's root are skipped.
+func TarFS(r io.Reader) (fs.FS, error) {
+	fsys := &memFS{entries: map[string]*memEntry{
+		".": {name: ".", mode: fs.ModeDir | 0o555},
+	}}
+
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read tar archive: %w", err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if name == "." || !fs.ValidPath(name) {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fsys.mkdirAll(name, hdr.ModTime)
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("could not read '%s' from tar archive: %w", hdr.Name, err)
+			}
+
+			fsys.mkdirAll(path.Dir(name), hdr.ModTime)
+			fsys.add(&memEntry{
+				name:    name,
+				data:    data,
+				mode:    fs.FileMode(hdr.Mode).Perm(),
+				modTime: hdr.ModTime,
+			})
+		}
+	}
+
+	return fsys, nil
+}
+
+// memFS is a read-only, in-memory fs.FS.
+type memFS struct {
+	entries map[string]*memEntry
+}
+
+type memEntry struct {
+	name     string
+	data     []byte
+	mode     fs.FileMode
+	modTime  time.Time
+	children []string
+}
+
+func (fsys *memFS) add(entry *memEntry) {
+	if _, exists := fsys.entries[entry.name]; !exists {
+		parent := fsys.entries[path.Dir(entry.name)]
+		parent.children = append(parent.children, entry.name)
+	}
+
+	fsys.entries[entry.name] = entry
+}
+
+func (fsys *memFS) mkdirAll(name string, modTime time.Time) {
+	if entry, exists := fsys.entries[name]; exists {
+		if entry.mode.IsDir() && modTime.After(entry.modTime) {
+			entry.modTime = modTime
+		}
+
+		return
+	}
+
+	fsys.mkdirAll(path.Dir(name), modTime)
+	fsys.add(&memEntry{name: name, mode: fs.ModeDir | 0o555, modTime: modTime})
+}
+
+func (fsys *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry, ok := fsys.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if entry.mode.IsDir() {
+		return &memDir{entry: entry, fsys: fsys}, nil
+	}
+
+	return &memFile{entry: entry, r: bytes.NewReader(entry.data)}, nil
+}
+
+func (entry *memEntry) Name() string       { return path.Base(entry.name) }
+func (entry *memEntry) Size() int64        { return int64(len(entry.data)) }
+func (entry *memEntry) Mode() fs.FileMode  { return entry.mode }
+func (entry *memEntry) ModTime() time.Time { return entry.modTime }
+func (entry *memEntry) IsDir() bool        { return entry.mode.IsDir() }
+func (entry *memEntry) Sys() any           { return nil }
+
+type memFile struct {
+	entry *memEntry
+	r     *bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *memFile) Close() error               { return nil }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	return f.r.Read(p) //nolint:wrapcheck
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	return f.r.Seek(offset, whence) //nolint:wrapcheck
+}
+
+type memDir struct {
+	entry  *memEntry
+	fsys   *memFS
+	offset int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	names := append([]string(nil), d.entry.children...)
+	sort.Strings(names)
+
+	rest := names[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+
+	entries := make([]fs.DirEntry, 0, len(rest))
+	for _, name := range rest {
+		entries = append(entries, fs.FileInfoToDirEntry(d.fsys.entries[name]))
+	}
+
+	d.offset += len(rest)
+
+	return entries, nil
+}
+
+// ArchiveFS is an fs.FS serving the contents of an archive file, which is
+// swapped atomically when the archive is reloaded. Deploying a new version
+// of a capsule is then a matter of replacing a single file; to avoid
+// loading a partially written archive, write it next to the old one and
+// rename it into place.
+type ArchiveFS struct {
+	fpath   string
+	current atomic.Pointer[fs.FS]
+	watch   modTimeWatch
+
+	// Logger receives reload errors from Watch. Nothing is logged if nil.
+	Logger *slog.Logger
+}
+
+// NewArchiveFS loads the archive at fpath. See: OpenArchive.
+func NewArchiveFS(fpath string) (*ArchiveFS, error) {
+	afs := &ArchiveFS{fpath: fpath, watch: modTimeWatch{files: []string{fpath}}}
+	if err := afs.Reload(); err != nil {
+		return nil, err
+	}
+
+	return afs, nil
+}
+
+// Open opens the named file in the current contents of the archive.
+func (afs *ArchiveFS) Open(name string) (fs.File, error) {
+	return (*afs.current.Load()).Open(name) //nolint:wrapcheck
+}
+
+// Reload loads the archive again and swaps the served contents. Files
+// already opened are not affected. On error, the contents are left as
+// they were.
+func (afs *ArchiveFS) Reload() error {
+	return afs.watch.reload(func() error {
+		fsys, err := OpenArchive(afs.fpath)
+		if err != nil {
+			return err
+		}
+
+		afs.current.Store(&fsys)
+
+		return nil
+	})
+}
+
+// Watch polls the modification time of the archive every interval,
+// DefaultArchiveReloadInterval if zero, reloading it when it changes. It
+// returns once ctx is done.
+func (afs *ArchiveFS) Watch(ctx context.Context, interval time.Duration) {
+	if interval == 0 {
+		interval = DefaultArchiveReloadInterval
+	}
+
+	afs.watch.watch(ctx, interval, afs.Logger, "archive", afs.Reload)
+}
@@ -0,0 +1,189 @@
+package libgemini
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// writeArchive writes files to an archive at fpath, using the format
+// matching its extension.
+func writeArchive(t *testing.T, fpath string, files map[string]string) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+
+	if strings.HasSuffix(fpath, ".zip") {
+		zw := zip.NewWriter(buf)
+
+		for name, content := range files {
+			fw, err := zw.Create(name)
+			if err != nil {
+				t.Fatalf("could not create '%s': %v", name, err)
+			}
+
+			fw.Write([]byte(content))
+		}
+
+		zw.Close()
+	} else {
+		tw := tar.NewWriter(buf)
+		tw.WriteHeader(&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0o755})
+
+		for name, content := range files {
+			tw.WriteHeader(&tar.Header{Name: "./" + name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})
+			tw.Write([]byte(content))
+		}
+
+		tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"})
+		tw.WriteHeader(&tar.Header{Name: "../escape", Typeflag: tar.TypeReg})
+		tw.Close()
+	}
+
+	data := buf.Bytes()
+
+	if strings.HasSuffix(fpath, "gz") {
+		gzBuf := &bytes.Buffer{}
+		gzw := gzip.NewWriter(gzBuf)
+		gzw.Write(data)
+		gzw.Close()
+
+		data = gzBuf.Bytes()
+	}
+
+	// NOTE: write then rename, as a deployment would.
+	tmp := fpath + ".tmp"
+	if err := os.WriteFile(tmp, data, UserRWAllR); err != nil {
+		t.Fatalf("could not write archive: %v", err)
+	}
+
+	if err := os.Rename(tmp, fpath); err != nil {
+		t.Fatalf("could not rename archive: %v", err)
+	}
+}
+
+var archiveTestFiles = map[string]string{
+	"index.gmi":        "# Home\n",
+	"docs/guide.gmi":   "# Guide\n",
+	"docs/img/a.png":   "png",
+	"about/index.gmi":  "# About\n",
+	"about/team.txt":   "team",
+	"docs/changes.gmi": "# Changes\n",
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"site.tar", "site.tar.gz", "site.tgz", "site.zip"} {
+		fpath := filepath.Join(dir, name)
+		writeArchive(t, fpath, archiveTestFiles)
+
+		fsys, err := OpenArchive(fpath)
+		if err != nil {
+			t.Fatalf("%s: could not open: %v", name, err)
+		}
+
+		expected := make([]string, 0, len(archiveTestFiles))
+		for file := range archiveTestFiles {
+			expected = append(expected, file)
+		}
+
+		if err := fstest.TestFS(fsys, expected...); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		for _, unexpected := range []string{"link", "escape"} {
+			if _, err := fs.Stat(fsys, unexpected); err == nil {
+				t.Errorf("%s: '%s' must not be extracted", name, unexpected)
+			}
+		}
+
+		rec := &recorder{}
+		FileServer(fsys).ServeGemini(rec, mustRequest(t, "gemini://example.com/docs/guide.gmi"))
+
+		if rec.status != Success || rec.body.String() != "# Guide\n" {
+			t.Errorf("%s: got '%d %s' %q", name, rec.status, rec.meta, rec.body.String())
+		}
+	}
+
+	if _, err := OpenArchive(filepath.Join(dir, "site.rar")); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestArchiveFS(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "site.tar.gz")
+	writeArchive(t, fpath, map[string]string{"index.gmi": "v1"})
+
+	afs, err := NewArchiveFS(fpath)
+	if err != nil {
+		t.Fatalf("could not open archive: %v", err)
+	}
+
+	body := func() string {
+		t.Helper()
+
+		data, err := fs.ReadFile(afs, "index.gmi")
+		if err != nil {
+			t.Fatalf("could not read index: %v", err)
+		}
+
+		return string(data)
+	}
+
+	if got := body(); got != "v1" {
+		t.Fatalf("got %q", got)
+	}
+
+	writeArchive(t, fpath, map[string]string{"index.gmi": "v2"})
+
+	if err := afs.Reload(); err != nil {
+		t.Fatalf("could not reload: %v", err)
+	}
+
+	if got := body(); got != "v2" {
+		t.Fatalf("got %q", got)
+	}
+
+	if err := os.WriteFile(fpath, []byte("garbage"), UserRWAllR); err != nil {
+		t.Fatalf("could not write archive: %v", err)
+	}
+
+	if err := afs.Reload(); err == nil {
+		t.Errorf("expected an error reloading an invalid archive")
+	}
+
+	if got := body(); got != "v2" {
+		t.Errorf("a failed reload must keep the previous contents, got %q", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go afs.Watch(ctx, 10*time.Millisecond)
+
+	writeArchive(t, fpath, map[string]string{"index.gmi": "v3"})
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(fpath, future, future); err != nil {
+		t.Fatalf("could not set modification time: %v", err)
+	}
+
+	deadline := time.Now().Add(testTimeout)
+	for body() != "v3" {
+		if time.Now().After(deadline) {
+			t.Fatalf("archive was not reloaded by Watch")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,176 @@
+package libgemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var ErrUnmatchedRequest = errors.New("no recorded interaction matches the request")
+
+// Interaction is a recorded exchange: the request's URL, the fingerprint of
+// the server's certificate and the response, using the same JSON shape as
+// testdata/response.json.
+type Interaction struct {
+	URL         string
+	Fingerprint string
+	Response    Response
+}
+
+// Cassette is a list of recorded interactions, stored as JSON lines: one
+// interaction per line, so that recording only appends to the file.
+// Clients append to one when recording is enabled (see: WithRecord), and
+// ReplayTransport serves responses from one.
+type Cassette struct {
+	Interactions []Interaction
+}
+
+// LoadCassette reads the cassette stored at path.
+func LoadCassette(path string) (*Cassette, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cassette '%s': %w", path, err)
+	}
+	defer fd.Close()
+
+	cassette := &Cassette{}
+	dec := json.NewDecoder(fd)
+
+	for {
+		interaction := Interaction{}
+
+		err := dec.Decode(&interaction)
+		if errors.Is(err, io.EOF) {
+			return cassette, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not parse cassette '%s': %w", path, err)
+		}
+
+		cassette.Interactions = append(cassette.Interactions, interaction)
+	}
+}
+
+// Save writes the cassette to path, replacing its contents.
+func (cassette *Cassette) Save(path string) error {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+
+	for _, interaction := range cassette.Interactions {
+		if err := enc.Encode(interaction); err != nil {
+			return fmt.Errorf("could not marshal cassette: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), UserRWXAllNone); err != nil {
+		return fmt.Errorf("could not create directory for '%s': %w", path, err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), UserRWAllR); err != nil {
+		return fmt.Errorf("could not write cassette '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// cassetteMu serializes appending to cassette files, which may be shared
+// by several clients.
+var cassetteMu sync.Mutex
+
+// recordInteraction appends the exchange to the cassette at path, creating
+// it if needed. The line is written at once, so that processes appending
+// to the same file don't interleave their interactions.
+func recordInteraction(path string, req Request, resp Response) error {
+	line, err := json.Marshal(Interaction{
+		URL:         req.String(),
+		Fingerprint: resp.fingerprint,
+		Response:    resp,
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal interaction: %w", err)
+	}
+
+	cassetteMu.Lock()
+	defer cassetteMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), UserRWXAllNone); err != nil {
+		return fmt.Errorf("could not create directory for '%s': %w", path, err)
+	}
+
+	fd, err := os.OpenFile(path, appendFileFlags, UserRWAllR)
+	if err != nil {
+		return fmt.Errorf("could not open cassette '%s': %w", path, err)
+	}
+	defer fd.Close()
+
+	if _, err := fd.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("could not write cassette '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// canonicalURL returns rawURL as a Request would send it, so hand-written
+// cassettes may omit the default port.
+func canonicalURL(rawURL string) string {
+	req, err := NewRequest(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	return req.String()
+}
+
+// ReplayTransport is a Transport serving responses recorded in a cassette,
+// for deterministic tests. Requests are matched by URL: interactions
+// recorded for the same URL are replayed in order, the last one being
+// repeated once all have been used. Unmatched requests fail with
+// ErrUnmatchedRequest.
+type ReplayTransport struct {
+	cassette *Cassette
+	mu       sync.Mutex
+	used     map[string]int
+}
+
+// NewReplayTransport returns a transport replaying cassette.
+func NewReplayTransport(cassette *Cassette) *ReplayTransport {
+	return &ReplayTransport{cassette: cassette, used: make(map[string]int)}
+}
+
+func (t *ReplayTransport) RoundTrip(ctx context.Context, req Request) (Response, error) {
+	if err := ctx.Err(); err != nil {
+		return Response{}, err //nolint:wrapcheck
+	}
+
+	rawURL := req.String()
+
+	matches := make([]Interaction, 0)
+
+	for _, interaction := range t.cassette.Interactions {
+		if canonicalURL(interaction.URL) == rawURL {
+			matches = append(matches, interaction)
+		}
+	}
+
+	if len(matches) == 0 {
+		return Response{}, fmt.Errorf("%w: '%s'", ErrUnmatchedRequest, rawURL)
+	}
+
+	t.mu.Lock()
+	k := min(t.used[rawURL], len(matches)-1)
+	t.used[rawURL]++
+	t.mu.Unlock()
+
+	resp := matches[k].Response
+	resp.Content = append([]byte(nil), resp.Content...)
+	resp.fingerprint = matches[k].Fingerprint
+
+	return resp, nil
+}
@@ -0,0 +1,148 @@
+package libgemini
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aalbacetef/tofu"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	cert := testCertificate(t, "localhost")
+	mux := NewServeMux()
+	mux.HandleFunc("/hello", func(w ResponseWriter, _ *Request) {
+		w.Write([]byte("hello"))
+	})
+
+	addr := startTestServer(t, &Server{
+		Handler:   mux,
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}},
+	})
+
+	cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+	t.Setenv(EnvRC, filepath.Join(t.TempDir(), "geminirc"))
+
+	recorder, err := NewClient(WithInMemoryStore(), WithInsecure(), WithRecord(cassettePath))
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+
+	urls := []string{"gemini://" + addr + "/hello", "gemini://" + addr + "/missing"}
+	for _, u := range urls {
+		if _, err := recorder.Get(u); err != nil {
+			t.Fatalf("could not get '%s': %v", u, err)
+		}
+	}
+
+	cassette, err := LoadCassette(cassettePath)
+	if err != nil {
+		t.Fatalf("could not load cassette: %v", err)
+	}
+
+	fingerprint := tofu.Fingerprint(cert.Leaf)
+
+	if n := len(cassette.Interactions); n != len(urls) {
+		t.Fatalf("got %d interactions, want %d", n, len(urls))
+	}
+
+	for k, interaction := range cassette.Interactions {
+		if interaction.URL != urls[k] || interaction.Fingerprint != fingerprint {
+			t.Errorf("unexpected interaction: %s %s", interaction.URL, interaction.Fingerprint)
+		}
+	}
+
+	replayer, err := NewClient(WithInMemoryStore())
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+
+	replayer.Transport = NewReplayTransport(cassette)
+
+	resp, err := replayer.Get(urls[0])
+	if err != nil {
+		t.Fatalf("could not replay: %v", err)
+	}
+
+	if resp.Header.Status != Success || string(resp.Content) != "hello" || resp.PeerFingerprint() != fingerprint {
+		t.Errorf("unexpected replayed response: %+v '%s' %s", resp.Header, resp.Content, resp.PeerFingerprint())
+	}
+
+	resp, err = replayer.Get(urls[1])
+	if err != nil || resp.Header.Status != NotFound {
+		t.Errorf("unexpected replayed response: %+v, %v", resp.Header, err)
+	}
+
+	if _, err := replayer.Get("gemini://" + addr + "/other"); !errors.Is(err, ErrUnmatchedRequest) {
+		t.Errorf("expected ErrUnmatchedRequest, got %v", err)
+	}
+
+	// NOTE: replayed exchanges must not be written back to the cassette.
+	recorder.Transport = NewReplayTransport(cassette)
+	if _, err := recorder.Get(urls[0]); err != nil {
+		t.Fatalf("could not replay: %v", err)
+	}
+
+	reloaded, err := LoadCassette(cassettePath)
+	if err != nil || len(reloaded.Interactions) != len(urls) {
+		t.Errorf("replaying changed the cassette: %v", err)
+	}
+}
+
+func TestCassetteSave(t *testing.T) {
+	cassette := &Cassette{Interactions: []Interaction{
+		{URL: "gemini://example.com/", Fingerprint: "ab:cd", Response: Response{Header: Header{Status: Success, Meta: "text/gemini"}, Content: []byte("one\ntwo")}},
+		{URL: "gemini://example.com/missing", Response: Response{Header: Header{Status: NotFound, Meta: "not found"}}},
+	}}
+
+	fpath := filepath.Join(t.TempDir(), "cassette.jsonl")
+	if err := cassette.Save(fpath); err != nil {
+		t.Fatalf("could not save: %v", err)
+	}
+
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		t.Fatalf("could not read cassette: %v", err)
+	}
+
+	if n := bytes.Count(data, []byte("\n")); n != len(cassette.Interactions) {
+		t.Errorf("expected one line per interaction, got %d lines", n)
+	}
+
+	loaded, err := LoadCassette(fpath)
+	if err != nil {
+		t.Fatalf("could not load: %v", err)
+	}
+
+	if len(loaded.Interactions) != 2 || loaded.Interactions[0].Fingerprint != "ab:cd" ||
+		string(loaded.Interactions[0].Response.Content) != "one\ntwo" ||
+		loaded.Interactions[1].Response.Header.Status != NotFound {
+		t.Errorf("unexpected cassette: %+v", loaded.Interactions)
+	}
+}
+
+func TestReplayTransportOrder(t *testing.T) {
+	cassette := &Cassette{Interactions: []Interaction{
+		{URL: "gemini://example.com/", Response: Response{Header: Header{Status: Success, Meta: "text/gemini"}, Content: []byte("one")}},
+		{URL: "gemini://example.com/", Response: Response{Header: Header{Status: Success, Meta: "text/gemini"}, Content: []byte("two")}},
+	}}
+
+	t.Setenv(EnvRC, filepath.Join(t.TempDir(), "geminirc"))
+
+	client, err := NewClient(WithInMemoryStore())
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+
+	client.Transport = NewReplayTransport(cassette)
+
+	for _, want := range []string{"one", "two", "two"} {
+		resp, err := client.Get("gemini://example.com/")
+		if err != nil || string(resp.Content) != want {
+			t.Errorf("got '%s' (%v), want '%s'", resp.Content, err, want)
+		}
+	}
+}
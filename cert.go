@@ -0,0 +1,216 @@
+package libgemini
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// KeyType is the type of key used by generated certificates.
+type KeyType int
+
+const (
+	KeyECDSA KeyType = iota
+	KeyEd25519
+)
+
+const (
+	// DefaultCertificateValidity is long on purpose: with TOFU, rotating a
+	// certificate makes clients warn about a possible attack.
+	DefaultCertificateValidity = 50 * 365 * 24 * time.Hour
+	UserRWAllNone              = fs.FileMode(0o600)
+	serialNumberBits           = 128
+	pemCertificate             = "CERTIFICATE"
+	pemPrivateKey              = "PRIVATE KEY"
+)
+
+var ErrNoHosts = errors.New("no hosts provided")
+
+// CertificateOptions controls the generation of self-signed certificates.
+type CertificateOptions struct {
+	// Hosts are the DNS names or IP addresses the certificate is valid for.
+	// The first one is used as the subject's common name.
+	Hosts []string
+
+	// Validity is how long the certificate is valid for,
+	// DefaultCertificateValidity if zero.
+	Validity time.Duration
+
+	// KeyType defaults to KeyECDSA, using the P-256 curve.
+	KeyType KeyType
+}
+
+// GenerateCertificate creates a self-signed certificate for opts.Hosts.
+func GenerateCertificate(opts CertificateOptions) (tls.Certificate, error) {
+	if len(opts.Hosts) == 0 {
+		return tls.Certificate{}, ErrNoHosts
+	}
+
+	validity := opts.Validity
+	if validity == 0 {
+		validity = DefaultCertificateValidity
+	}
+
+	pub, priv, err := generateKey(opts.KeyType)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialNumberBits))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: opts.Hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	for _, host := range opts.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, pub, priv)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not create certificate: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not parse certificate: %w", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv, Leaf: leaf}, nil
+}
+
+func generateKey(keyType KeyType) (crypto.PublicKey, crypto.Signer, error) {
+	switch keyType {
+	case KeyEd25519:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not generate ed25519 key: %w", err)
+		}
+
+		return pub, priv, nil
+	case KeyECDSA:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not generate ecdsa key: %w", err)
+		}
+
+		return &priv.PublicKey, priv, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown key type %d", keyType)
+	}
+}
+
+// WriteCertificate saves cert and its private key as PEM files. The key
+// file is only readable by the current user.
+func WriteCertificate(cert tls.Certificate, certFile, keyFile string) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("could not marshal private key: %w", err)
+	}
+
+	certPEM := make([]byte, 0)
+	for _, der := range cert.Certificate {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: pemCertificate, Bytes: der})...)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: pemPrivateKey, Bytes: keyDER})
+
+	for _, fpath := range []string{certFile, keyFile} {
+		if err := os.MkdirAll(filepath.Dir(fpath), UserRWXAllNone); err != nil {
+			return fmt.Errorf("could not create directory for '%s': %w", fpath, err)
+		}
+	}
+
+	if err := os.WriteFile(keyFile, keyPEM, UserRWAllNone); err != nil {
+		return fmt.Errorf("could not write key '%s': %w", keyFile, err)
+	}
+
+	if err := os.WriteFile(certFile, certPEM, UserRWAllR); err != nil {
+		return fmt.Errorf("could not write certificate '%s': %w", certFile, err)
+	}
+
+	return nil
+}
+
+// LoadOrGenerateCertificate loads the certificate from certFile and
+// keyFile. If certFile does not exist, a certificate is generated using
+// opts and saved, so that it is reused on the next start.
+func LoadOrGenerateCertificate(certFile, keyFile string, opts CertificateOptions) (tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err == nil {
+		return cert, nil
+	}
+
+	if _, statErr := os.Stat(certFile); !errors.Is(statErr, fs.ErrNotExist) {
+		return tls.Certificate{}, fmt.Errorf("could not load certificate '%s': %w", certFile, err)
+	}
+
+	cert, err = GenerateCertificate(opts)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if err := WriteCertificate(cert, certFile, keyFile); err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return cert, nil
+}
+
+// DefaultCertDir returns the directory where Server stores generated
+// certificates: $HOME/.config/libgemini/certs.
+func DefaultCertDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".config", "libgemini", "certs"), nil
+}
+
+// autoCertificates loads or generates one certificate per hostname in dir,
+// using the same layout as VirtualHosts.AddFromDir.
+func autoCertificates(dir string, hostnames []string) ([]tls.Certificate, error) {
+	certs := make([]tls.Certificate, 0, len(hostnames))
+
+	for _, hostname := range hostnames {
+		certFile, keyFile := hostCertificatePaths(dir, hostname)
+
+		cert, err := LoadOrGenerateCertificate(certFile, keyFile, CertificateOptions{Hosts: []string{hostname}})
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,65 @@
+package libgemini
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadOrGenerateCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	opts := CertificateOptions{Hosts: []string{"example.com", "127.0.0.1"}, KeyType: KeyEd25519}
+
+	first, err := LoadOrGenerateCertificate(certFile, keyFile, opts)
+	if err != nil {
+		t.Fatalf("could not generate certificate: %v", err)
+	}
+
+	leaf := first.Leaf
+	if leaf.Subject.CommonName != "example.com" || len(leaf.DNSNames) != 1 || len(leaf.IPAddresses) != 1 {
+		t.Fatalf("unexpected certificate: %+v", leaf)
+	}
+
+	if _, ok := first.PrivateKey.(ed25519.PrivateKey); !ok {
+		t.Fatalf("expected an ed25519 key, got %T", first.PrivateKey)
+	}
+
+	if validity := time.Until(leaf.NotAfter); validity < DefaultCertificateValidity-2*time.Hour {
+		t.Fatalf("certificate only valid for %s", validity)
+	}
+
+	second, err := LoadOrGenerateCertificate(certFile, keyFile, opts)
+	if err != nil {
+		t.Fatalf("could not load certificate: %v", err)
+	}
+
+	if !bytes.Equal(first.Certificate[0], second.Certificate[0]) {
+		t.Fatalf("certificate was regenerated instead of reused")
+	}
+}
+
+func TestServerAutoCertificates(t *testing.T) {
+	dir := t.TempDir()
+	handler := HandlerFunc(func(w ResponseWriter, _ *Request) {
+		w.Write([]byte("ok"))
+	})
+
+	addr := startTestServer(t, &Server{Handler: handler, CertDir: dir, Hostnames: []string{"capsule.test"}})
+
+	resp, cert := rawGet(t, addr, "capsule.test", "gemini://capsule.test/")
+	if resp.Header.Status != Success || cert != "capsule.test" {
+		t.Fatalf("got (%d, %s)", resp.Header.Status, cert)
+	}
+
+	vh, err := LoadVirtualHosts(dir, func(string) Handler { return handler })
+	if err != nil {
+		t.Fatalf("could not load virtual hosts: %v", err)
+	}
+
+	if _, found := vh.lookup("capsule.test"); !found {
+		t.Fatalf("generated certificate not found by LoadVirtualHosts")
+	}
+}
@@ -0,0 +1,75 @@
+package libgemini
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultCertReloadInterval = 10 * time.Second
+
+// CertificateReloader serves a certificate loaded from a pair of PEM files,
+// swapping it atomically when it is reloaded, so that rotating a
+// certificate does not drop connections. Use its GetCertificate method in
+// a tls.Config.
+//
+// If reloading fails, for instance because the files are being replaced,
+// the previous certificate keeps being served.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+	watch    modTimeWatch
+
+	// Logger receives reload errors from Watch. Nothing is logged if nil.
+	Logger *slog.Logger
+}
+
+// NewCertificateReloader loads the certificate from certFile and keyFile.
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	cr := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		watch:    modTimeWatch{files: []string{certFile, keyFile}},
+	}
+	if err := cr.Reload(); err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+// Reload loads the certificate files again and swaps the served
+// certificate. Connections in progress are not affected.
+func (cr *CertificateReloader) Reload() error {
+	return cr.watch.reload(func() error {
+		cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+		if err != nil {
+			return fmt.Errorf("could not load certificate '%s': %w", cr.certFile, err)
+		}
+
+		cr.cert.Store(&cert)
+
+		return nil
+	})
+}
+
+// GetCertificate returns the current certificate. Its signature matches
+// tls.Config.GetCertificate.
+func (cr *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cr.cert.Load(), nil
+}
+
+// Watch polls the modification times of the certificate files every
+// interval, DefaultCertReloadInterval if zero, reloading the certificate
+// when they change. It returns once ctx is done.
+func (cr *CertificateReloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval == 0 {
+		interval = DefaultCertReloadInterval
+	}
+
+	cr.watch.watch(ctx, interval, cr.Logger, "certificate", cr.Reload)
+}
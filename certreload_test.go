@@ -0,0 +1,102 @@
+package libgemini
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCertificate(t *testing.T, certFile, keyFile, host string, modTime time.Time) {
+	t.Helper()
+
+	if err := WriteCertificate(testCertificate(t, host), certFile, keyFile); err != nil {
+		t.Fatalf("could not write certificate: %v", err)
+	}
+
+	for _, fpath := range []string{certFile, keyFile} {
+		if err := os.Chtimes(fpath, modTime, modTime); err != nil {
+			t.Fatalf("could not set modification time: %v", err)
+		}
+	}
+}
+
+func reloaderCN(t *testing.T, cr *CertificateReloader) string {
+	t.Helper()
+
+	cert, err := cr.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("could not get certificate: %v", err)
+	}
+
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.crt"), filepath.Join(dir, "cert.key")
+	now := time.Now()
+
+	writeTestCertificate(t, certFile, keyFile, "a.test", now.Add(-time.Hour))
+
+	cr, err := NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("could not create reloader: %v", err)
+	}
+
+	addr := startTestServer(t, &Server{
+		Handler:   NotFoundHandler(),
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: cr.GetCertificate},
+	})
+
+	if _, cn := rawGet(t, addr, "a.test", "gemini://a.test/"); cn != "a.test" {
+		t.Fatalf("got certificate for '%s', want 'a.test'", cn)
+	}
+
+	writeTestCertificate(t, certFile, keyFile, "b.test", now)
+
+	if err := cr.Reload(); err != nil {
+		t.Fatalf("could not reload: %v", err)
+	}
+
+	if _, cn := rawGet(t, addr, "b.test", "gemini://b.test/"); cn != "b.test" {
+		t.Fatalf("got certificate for '%s', want 'b.test'", cn)
+	}
+
+	if err := os.WriteFile(certFile, []byte("garbage"), UserRWAllR); err != nil {
+		t.Fatalf("could not write file: %v", err)
+	}
+
+	if err := cr.Reload(); err == nil {
+		t.Errorf("expected an error reloading an invalid certificate")
+	}
+
+	if cn := reloaderCN(t, cr); cn != "b.test" {
+		t.Errorf("a failed reload must keep the previous certificate, got '%s'", cn)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go cr.Watch(ctx, 10*time.Millisecond)
+
+	writeTestCertificate(t, certFile, keyFile, "c.test", now.Add(time.Hour))
+
+	deadline := time.Now().Add(testTimeout)
+	for reloaderCN(t, cr) != "c.test" {
+		if time.Now().After(deadline) {
+			t.Fatalf("certificate was not reloaded by Watch")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerReloadCertificatesWithoutFiles(t *testing.T) {
+	srv := &Server{}
+	if err := srv.ReloadCertificates(); err == nil {
+		t.Errorf("expected an error")
+	}
+}
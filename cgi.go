@@ -0,0 +1,281 @@
+package libgemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultCGITimeout = 10 * time.Second
+	cgiWaitDelay      = time.Second
+	maxCGIStderr      = 4 * kb
+	executableBits    = 0o111
+)
+
+// CGIHandler executes scripts found in Dir, using the request's path to
+// find them. Path segments after the script's name are passed in PATH_INFO.
+//
+// Scripts receive the de-facto Gemini CGI environment (GEMINI_URL,
+// SCRIPT_NAME, PATH_INFO, QUERY_STRING, SERVER_NAME, REMOTE_ADDR, and
+// TLS_CLIENT_HASH, REMOTE_USER and friends when a client certificate was
+// presented) and must write a complete Gemini response, header included,
+// to stdout. It is streamed to the client as it is produced.
+//
+// Scripts that fail or time out before writing a valid header are
+// answered with CGIError.
+type CGIHandler struct {
+	// Dir is the directory containing the scripts.
+	Dir string
+
+	// Timeout bounds the execution of a script, DefaultCGITimeout if zero.
+	Timeout time.Duration
+
+	// Env holds extra environment variables, in the form "KEY=value".
+	Env []string
+
+	// Logger receives script errors and stderr output. Nothing is logged
+	// if nil.
+	Logger *slog.Logger
+}
+
+func (h *CGIHandler) ServeGemini(w ResponseWriter, r *Request) {
+	logger := loggerOrNoop(h.Logger)
+
+	scriptPath, scriptName, pathInfo, found := h.resolveScript(r.u.Path)
+	if !found {
+		Error(w, NotFound, "not found")
+
+		return
+	}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultCGITimeout
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	stderr := &limitedBuffer{max: maxCGIStderr}
+
+	cmd := exec.CommandContext(ctx, scriptPath) //nolint:gosec
+	cmd.Dir = filepath.Dir(scriptPath)
+	cmd.Env = append(envList(cgiEnv(r, scriptName, pathInfo)), h.Env...)
+	cmd.Stderr = stderr
+	cmd.WaitDelay = cgiWaitDelay
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		logger.Error("could not create stdout pipe", "script", scriptPath, "error", err)
+		Error(w, CGIError, "CGI error")
+
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		logger.Error("could not start script", "script", scriptPath, "error", err)
+		Error(w, CGIError, "CGI error")
+
+		return
+	}
+
+	if !streamCGIResponse(w, bufio.NewReader(stdout), logger, scriptPath) {
+		// NOTE: the script's output is invalid, or nobody is reading it
+		// anymore: stop it.
+		cancel()
+	}
+
+	if err := cmd.Wait(); err != nil {
+		logger.Error("script failed", "script", scriptPath, "error", err, "stderr", stderr.String())
+	}
+}
+
+// streamCGIResponse relays a Gemini response written by a gateway
+// program, answering CGIError if its header is invalid, or ProxyError if
+// reading it timed out. It reports whether the whole response was consumed:
+// after a valid header without body, the rest of the output is discarded,
+// so that the program can exit on its own.
+func streamCGIResponse(w ResponseWriter, br *bufio.Reader, logger *slog.Logger, source string) bool {
+	header, err := ReadHeader(br)
+	if err == nil {
+		err = validHeader(header.Status, header.Meta)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		logger.Error("gateway timed out", "source", source, "error", err)
+		Error(w, ProxyError, "gateway timeout")
+
+		return false
+	}
+
+	if err != nil {
+		logger.Error("invalid gateway response", "source", source, "error", err)
+		Error(w, CGIError, "CGI error")
+
+		return false
+	}
+
+	w.WriteHeader(header.Status, header.Meta)
+
+	if !header.Status.IsSuccess() {
+		_, err := io.Copy(io.Discard, br)
+
+		return err == nil
+	}
+
+	if _, err := io.Copy(w, br); err != nil {
+		logger.Error("could not relay gateway response", "source", source, "error", err)
+
+		return false
+	}
+
+	return true
+}
+
+// resolveScript walks the segments of upath inside h.Dir until it finds an
+// executable file. The script's path is absolute, as scripts run in their
+// own directory.
+func (h *CGIHandler) resolveScript(upath string) (string, string, string, bool) {
+	segs := strings.Split(strings.Trim(upath, "/"), "/")
+
+	cur, err := filepath.Abs(h.Dir)
+	if err != nil {
+		return "", "", "", false
+	}
+
+	for k, seg := range segs {
+		if seg == "" || seg == ".." || strings.HasPrefix(seg, ".") {
+			return "", "", "", false
+		}
+
+		cur = filepath.Join(cur, seg)
+
+		info, err := os.Stat(cur)
+		if err != nil {
+			return "", "", "", false
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		if !info.Mode().IsRegular() || info.Mode().Perm()&executableBits == 0 {
+			return "", "", "", false
+		}
+
+		scriptName := "/" + strings.Join(segs[:k+1], "/")
+
+		pathInfo := ""
+		if rest := segs[k+1:]; len(rest) > 0 {
+			pathInfo = "/" + strings.Join(rest, "/")
+		}
+
+		return cur, scriptName, pathInfo, true
+	}
+
+	return "", "", "", false
+}
+
+const tlsClientHashPrefix = "SHA256:"
+
+// tlsClientHash returns the SHA-256 hash of the certificate's DER encoding,
+// as an uppercase hex string prefixed by "SHA256:", the format CGI scripts
+// expect in TLS_CLIENT_HASH.
+func tlsClientHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+
+	return tlsClientHashPrefix + strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// cgiEnv builds the de-facto Gemini CGI environment for r.
+func cgiEnv(r *Request, scriptName, pathInfo string) map[string]string {
+	port := r.u.Port()
+	if port == "" {
+		port = strconv.Itoa(geminiPort)
+	}
+
+	env := map[string]string{
+		"GATEWAY_INTERFACE": "CGI/1.1",
+		"SERVER_PROTOCOL":   "GEMINI",
+		"SERVER_SOFTWARE":   "libgemini",
+		"GEMINI_URL":        r.u.String(),
+		"SCRIPT_NAME":       scriptName,
+		"PATH_INFO":         pathInfo,
+		"QUERY_STRING":      r.u.RawQuery,
+		"SERVER_NAME":       r.u.Hostname(),
+		"SERVER_PORT":       port,
+	}
+
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		env["REMOTE_ADDR"] = host
+		env["REMOTE_HOST"] = host
+	}
+
+	if path, set := os.LookupEnv("PATH"); set {
+		env["PATH"] = path
+	}
+
+	if r.TLS != nil {
+		env["TLS_VERSION"] = tls.VersionName(r.TLS.Version)
+		env["TLS_CIPHER"] = tls.CipherSuiteName(r.TLS.CipherSuite)
+	}
+
+	if cert := r.ClientCertificate(); cert != nil {
+		env["AUTH_TYPE"] = "Certificate"
+		env["REMOTE_USER"] = cert.Subject.CommonName
+		env["TLS_CLIENT_HASH"] = tlsClientHash(cert)
+		env["TLS_CLIENT_SUBJECT"] = cert.Subject.String()
+		env["TLS_CLIENT_NOT_BEFORE"] = cert.NotBefore.UTC().Format(time.RFC3339)
+		env["TLS_CLIENT_NOT_AFTER"] = cert.NotAfter.UTC().Format(time.RFC3339)
+		env["TLS_CLIENT_SERIAL_NUMBER"] = cert.SerialNumber.String()
+	}
+
+	return env
+}
+
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for key, val := range env {
+		list = append(list, key+"="+val)
+	}
+
+	sort.Strings(list)
+
+	return list
+}
+
+// limitedBuffer keeps the first max bytes written to it, discarding
+// the rest.
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	if room := lb.max - lb.buf.Len(); room > 0 {
+		lb.buf.Write(p[:min(room, len(p))])
+	}
+
+	return len(p), nil
+}
+
+func (lb *limitedBuffer) String() string {
+	return lb.buf.String()
+}
@@ -0,0 +1,126 @@
+package libgemini
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, dir, name, body string) {
+	t.Helper()
+
+	fpath := filepath.Join(dir, name)
+	if err := os.WriteFile(fpath, []byte("#!/bin/sh\n"+body), 0o755); err != nil { //nolint:gosec
+		t.Fatalf("could not write script: %v", err)
+	}
+}
+
+func TestCGIHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	writeScript(t, dir, "env.sh", `printf '20 text/plain\r\n'
+printf '%s|%s|%s|%s' "$GEMINI_URL" "$SCRIPT_NAME" "$PATH_INFO" "$QUERY_STRING"
+`)
+	writeScript(t, dir, "fail.sh", "exit 1\n")
+	writeScript(t, dir, "slow.sh", "sleep 5\n")
+	writeScript(t, dir, "input.sh", `printf '10 Your name?\r\n'`)
+
+	if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte("data"), 0o644); err != nil { //nolint:gosec
+		t.Fatalf("could not write file: %v", err)
+	}
+
+	handler := &CGIHandler{Dir: dir, Timeout: 500 * time.Millisecond}
+
+	cases := []struct {
+		url    string
+		status StatusCode
+		meta   string
+		body   string
+	}{
+		{
+			"gemini://example.com/env.sh/extra/path?q=1",
+			Success, "text/plain",
+			"gemini://example.com/env.sh/extra/path?q=1|/env.sh|/extra/path|q=1",
+		},
+		{"gemini://example.com/input.sh", Input, "Your name?", ""},
+		{"gemini://example.com/fail.sh", CGIError, "CGI error", ""},
+		{"gemini://example.com/slow.sh", CGIError, "CGI error", ""},
+		{"gemini://example.com/data.txt", NotFound, "not found", ""},
+		{"gemini://example.com/missing.sh", NotFound, "not found", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(tt *testing.T) {
+			rec := &recorder{}
+			handler.ServeGemini(rec, mustRequest(tt, c.url))
+
+			if rec.status != c.status || rec.meta != c.meta || !strings.HasPrefix(rec.body.String(), c.body) {
+				tt.Fatalf("got (%d, %q, %q), want (%d, %q, %q)", rec.status, rec.meta, rec.body.String(), c.status, c.meta, c.body)
+			}
+		})
+	}
+}
+
+func TestCGIHandlerRelativeDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "cgi"), 0o755); err != nil { //nolint:gosec
+		t.Fatalf("could not create directory: %v", err)
+	}
+
+	writeScript(t, filepath.Join(root, "cgi"), "ask", `printf '20 text/plain\r\nok'`)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not get working directory: %v", err)
+	}
+
+	if err := os.Chdir(root); err != nil {
+		t.Fatalf("could not change directory: %v", err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) }) //nolint:errcheck
+
+	rec := &recorder{}
+	(&CGIHandler{Dir: "cgi"}).ServeGemini(rec, mustRequest(t, "gemini://example.com/ask"))
+
+	if rec.status != Success || rec.body.String() != "ok" {
+		t.Errorf("got '%d %s' '%s'", rec.status, rec.meta, rec.body.String())
+	}
+}
+
+func TestCGIHandlerNonSuccessStatus(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "input.sh", `printf '10 Name?\r\n'`)
+	writeScript(t, dir, "redirect.sh", `printf '30 /elsewhere\r\n'
+sleep 0.1
+`)
+
+	logs := &bytes.Buffer{}
+	handler := &CGIHandler{Dir: dir, Logger: slog.New(slog.NewJSONHandler(logs, nil))}
+
+	cases := []struct {
+		url    string
+		status StatusCode
+		meta   string
+	}{
+		{"gemini://example.com/input.sh", Input, "Name?"},
+		{"gemini://example.com/redirect.sh", RedirectTemporary, "/elsewhere"},
+	}
+
+	for _, c := range cases {
+		rec := &recorder{}
+		handler.ServeGemini(rec, mustRequest(t, c.url))
+
+		if rec.status != c.status || rec.meta != c.meta {
+			t.Errorf("%s: got '%d %s'", c.url, rec.status, rec.meta)
+		}
+	}
+
+	if logs.Len() != 0 {
+		t.Errorf("expected nothing to be logged, got %s", logs.String())
+	}
+}
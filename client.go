@@ -1,9 +1,12 @@
 package libgemini
 
 import (
+	"bufio"
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
+	"sync"
 
 	"github.com/aalbacetef/tofu"
 )
@@ -17,7 +20,13 @@ func NewClient(userOpts ...OptsFn) (*Client, error) {
 
 type Client struct {
 	TLSConfig *tls.Config
-	userOpts  []OptsFn
+
+	// Transport, if set, performs requests instead of the network. See:
+	// ReplayTransport.
+	Transport Transport
+
+	userOpts []OptsFn
+	mu       sync.Mutex
 	Options
 }
 
@@ -28,6 +37,17 @@ func (c *Client) refresh() {
 	c.TLSConfig = tlsConfigFromOptions(options)
 }
 
+// snapshot refreshes the client and returns a copy of its options and TLS
+// config, so that concurrent requests don't race on them.
+func (c *Client) snapshot() (Options, *tls.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refresh()
+
+	return c.Options, c.TLSConfig.Clone()
+}
+
 const (
 	// NOTE: read section 4.1 of the spec.
 	minTLSVersion = tls.VersionTLS12
@@ -53,11 +73,18 @@ func tlsConfigFromOptions(options Options) *tls.Config {
 }
 
 func resolveOptions(userOptions ...OptsFn) Options {
-	options := mergeOpts(
-		defaultOpts(),
-		configOpts(resolveConfigFile()),
-		envOpts(),
-	)
+	options := defaultOpts()
+	for _, fn := range userOptions {
+		fn(&options)
+	}
+
+	if !options.SkipConfig {
+		options = mergeOpts(
+			defaultOpts(),
+			configOpts(resolveConfigFile()),
+			envOpts(),
+		)
+	}
 
 	for _, fn := range userOptions {
 		fn(&options)
@@ -124,61 +151,144 @@ func (c *Client) Do(req Request) (Response, error) {
 }
 
 // DoWithContext will dial the host, connect to it, finally writing the request on the
-// connection.
+// connection. If c.Transport is set, it is used instead of the network.
+//
+// If recording is enabled (see: WithRecord), the exchange is appended to
+// the cassette file, unless it was performed by c.Transport.
 func (c *Client) DoWithContext(_ctx context.Context, req Request) (Response, error) {
 	ctx, cancel := context.WithCancel(_ctx)
 	defer cancel()
 
-	c.refresh()
+	options, cfg := c.snapshot()
+
+	transport := c.Transport
+	if transport == nil {
+		transport = &netTransport{options: options, cfg: cfg}
+	}
 
-	traceLogger, err := NewLoggerFromPath(ctx, c.Options.Trace)
+	resp, err := transport.RoundTrip(ctx, req)
 	if err != nil {
-		return Response{}, err
+		return resp, err
+	}
+
+	resp.req = req
+
+	if err := logHeaders(ctx, options, req, resp.Header); err != nil {
+		return resp, err
+	}
+
+	// NOTE: exchanges performed by a Transport, such as a ReplayTransport,
+	// are not recorded, so that replaying never grows a cassette.
+	if options.RecordPath != "" && c.Transport == nil {
+		if err := recordInteraction(options.RecordPath, req, resp); err != nil {
+			return resp, err
+		}
+	}
+
+	return resp, nil
+}
+
+// StreamWithContext works like DoWithContext, except the body is not read
+// into memory: it is returned as an io.ReadCloser reading directly from the
+// TLS connection. The caller must close the body, and the connection is
+// closed when ctx is done.
+//
+// If c.Transport is set, the response it returns is streamed from memory.
+// Streamed exchanges are never recorded.
+func (c *Client) StreamWithContext(_ctx context.Context, req Request) (*StreamResponse, error) {
+	if c.Transport != nil {
+		return c.streamFromTransport(_ctx, req)
 	}
 
-	traceLogger.Info("Client.DoWithContext", "options", c.Options)
+	ctx, cancel := context.WithCancel(_ctx)
+	defer cancel()
+
+	options, cfg := c.snapshot()
+
+	conn, err := connect(ctx, options, cfg, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := context.AfterFunc(_ctx, func() {
+		conn.Close()
+	})
+
+	r := bufio.NewReader(conn)
+
+	header, err := ReadHeader(r)
+	if err != nil {
+		stop()
+		conn.Close()
+
+		return nil, err
+	}
+
+	if err := logHeaders(ctx, options, req, header); err != nil {
+		stop()
+		conn.Close()
+
+		return nil, err
+	}
+
+	resp := &StreamResponse{
+		Header: header,
+		Body:   &streamBody{r: r, conn: conn, stop: stop},
+		req:    req,
+	}
+
+	return resp, nil
+}
+
+// connect will dial the host and write the request on the connection.
+func connect(ctx context.Context, options Options, cfg *tls.Config, req Request) (net.Conn, error) {
+	traceLogger, err := NewLoggerFromPath(ctx, options.Trace)
+	if err != nil {
+		return nil, err
+	}
+
+	traceLogger.Info("Client.connect", "options", options)
 
-	cfg := c.TLSConfig.Clone()
 	cfg.ServerName = req.u.Hostname()
 
 	traceLogger.Info(
 		"tls config",
 		"ServerName", cfg.ServerName,
 		"MinVersion", cfg.MinVersion,
-		"bypassing TOFU", c.Options.Insecure,
+		"bypassing TOFU", options.Insecure,
 	)
 
-	headersLogger, err := NewLoggerFromPath(ctx, c.Options.DumpHeaders)
-	if err != nil {
-		return Response{}, err
-	}
-
 	d := tls.Dialer{
 		Config: cfg,
 	}
 
 	conn, err := d.DialContext(ctx, "tcp", req.u.Host)
 	if err != nil {
-		return Response{}, fmt.Errorf("error dialing (%s): %w", req.u.Host, err)
+		return nil, fmt.Errorf("error dialing (%s): %w", req.u.Host, err)
 	}
-	defer conn.Close()
 
 	if sendErr := req.Write(conn); sendErr != nil {
-		return Response{}, fmt.Errorf("error making request: %w", sendErr)
+		conn.Close()
+
+		return nil, fmt.Errorf("error making request: %w", sendErr)
 	}
 
-	resp, err := ReadResponse(conn)
+	return conn, nil
+}
+
+func logHeaders(ctx context.Context, options Options, req Request, header Header) error {
+	headersLogger, err := NewLoggerFromPath(ctx, options.DumpHeaders)
 	if err != nil {
-		return resp, err
+		return err
 	}
 
 	headersLogger.Info(
 		"Headers",
-		"Host", cfg.ServerName,
+		"Host", req.u.Hostname(),
 		"URL", req.String(),
-		"Meta", resp.Header.Meta,
-		"Status", resp.Header.Status,
+		"Meta", header.Meta,
+		"Status", header.Status,
 	)
 
-	return resp, nil
+	return nil
 }
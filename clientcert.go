@@ -0,0 +1,98 @@
+package libgemini
+
+import (
+	"crypto/x509"
+	"strings"
+	"time"
+
+	"github.com/aalbacetef/tofu"
+)
+
+// CertificateFingerprint returns the fingerprint of the certificate, as
+// computed by tofu.Fingerprint. This is the format used by the client, the
+// known hosts file, Response.PeerFingerprint and cassettes, so their
+// values can be passed to AllowClientCertificates.
+func CertificateFingerprint(cert *x509.Certificate) string {
+	return tofu.Fingerprint(cert)
+}
+
+// ClientCertificate returns the certificate presented by the client, or
+// nil if there is none.
+func (r Request) ClientCertificate() *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+
+	return r.TLS.PeerCertificates[0]
+}
+
+// ClientFingerprint returns the fingerprint of the certificate presented
+// by the client, or an empty string if there is none.
+// See: CertificateFingerprint.
+func (r Request) ClientFingerprint() string {
+	cert := r.ClientCertificate()
+	if cert == nil {
+		return ""
+	}
+
+	return CertificateFingerprint(cert)
+}
+
+// checkClientCertificate answers the request and returns false if no
+// client certificate was presented or if it is outside its validity period.
+func checkClientCertificate(w ResponseWriter, r *Request) bool {
+	cert := r.ClientCertificate()
+	if cert == nil {
+		Error(w, ClientCertificatedRequired, "client certificate required")
+
+		return false
+	}
+
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		Error(w, CertificateNotValid, "certificate expired or not yet valid")
+
+		return false
+	}
+
+	return true
+}
+
+// RequireClientCertificate wraps h, answering ClientCertificatedRequired
+// when no client certificate is presented, and CertificateNotValid when it
+// is expired or not yet valid.
+func RequireClientCertificate(h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		if !checkClientCertificate(w, r) {
+			return
+		}
+
+		h.ServeGemini(w, r)
+	})
+}
+
+// AllowClientCertificates returns a middleware working like
+// RequireClientCertificate, which also answers CertificateNotAuthorized
+// when the certificate's fingerprint is not one of fingerprints.
+func AllowClientCertificates(fingerprints ...string) Middleware {
+	allowed := make(map[string]struct{}, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		allowed[strings.ToUpper(fingerprint)] = struct{}{}
+	}
+
+	return func(h Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			if !checkClientCertificate(w, r) {
+				return
+			}
+
+			if _, found := allowed[strings.ToUpper(r.ClientFingerprint())]; !found {
+				Error(w, CertificateNotAuthorized, "certificate not authorized")
+
+				return
+			}
+
+			h.ServeGemini(w, r)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package libgemini
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aalbacetef/tofu"
+)
+
+func withClientCert(t *testing.T, rawURL string, cert *tls.Certificate) *Request {
+	t.Helper()
+
+	req := mustRequest(t, rawURL)
+	req.TLS = &tls.ConnectionState{}
+
+	if cert != nil {
+		req.TLS.PeerCertificates = append(req.TLS.PeerCertificates, cert.Leaf)
+	}
+
+	return req
+}
+
+func TestClientCertificates(t *testing.T) {
+	alice := testCertificate(t, "alice")
+	bob := testCertificate(t, "bob")
+
+	expired, err := GenerateCertificate(CertificateOptions{Hosts: []string{"old"}, Validity: -time.Minute})
+	if err != nil {
+		t.Fatalf("could not generate certificate: %v", err)
+	}
+
+	ok := HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Write([]byte(r.ClientCertificate().Subject.CommonName))
+	})
+
+	// NOTE: fingerprints use the same format as the known hosts file.
+	handler := AllowClientCertificates(tofu.Fingerprint(alice.Leaf))(ok)
+
+	cases := []struct {
+		label  string
+		cert   *tls.Certificate
+		status StatusCode
+	}{
+		{"no certificate", nil, ClientCertificatedRequired},
+		{"expired", &expired, CertificateNotValid},
+		{"not allowed", &bob, CertificateNotAuthorized},
+		{"allowed", &alice, Success},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(tt *testing.T) {
+			rec := &recorder{}
+			handler.ServeGemini(rec, withClientCert(tt, "gemini://example.com/", c.cert))
+
+			if rec.status != c.status {
+				tt.Fatalf("got status %d, want %d", rec.status, c.status)
+			}
+		})
+	}
+
+	t.Run("RequireClientCertificate accepts any valid certificate", func(tt *testing.T) {
+		rec := &recorder{}
+		RequireClientCertificate(ok).ServeGemini(rec, withClientCert(tt, "gemini://example.com/", &bob))
+
+		if rec.status != Success || rec.body.String() != "bob" {
+			tt.Fatalf("got (%d, %q)", rec.status, rec.body.String())
+		}
+	})
+
+	t.Run("the server requests client certificates", func(tt *testing.T) {
+		addr := startTestServer(tt, &Server{Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+			w.Write([]byte(r.ClientFingerprint()))
+		})})
+
+		conn, err := tls.Dial("tcp", addr, &tls.Config{
+			InsecureSkipVerify: true, //nolint:gosec
+			Certificates:       []tls.Certificate{alice},
+		})
+		if err != nil {
+			tt.Fatalf("could not dial: %v", err)
+		}
+		defer conn.Close()
+
+		io.WriteString(conn, "gemini://localhost/"+CRLF)
+
+		resp, err := ReadResponse(bufio.NewReader(conn))
+		if err != nil {
+			tt.Fatalf("could not read response: %v", err)
+		}
+
+		if got := string(resp.Content); !strings.EqualFold(got, CertificateFingerprint(alice.Leaf)) {
+			tt.Fatalf("got fingerprint %q", got)
+		}
+	})
+}
+
+func TestCGIClientHash(t *testing.T) {
+	cert := testCertificate(t, "alice")
+	env := cgiEnv(withClientCert(t, "gemini://example.com/", &cert), "/", "")
+
+	hash := env["TLS_CLIENT_HASH"]
+	if !strings.HasPrefix(hash, "SHA256:") || len(hash) != len("SHA256:")+64 || hash != strings.ToUpper(hash) {
+		t.Errorf("unexpected TLS_CLIENT_HASH '%s'", hash)
+	}
+}
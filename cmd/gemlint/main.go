@@ -0,0 +1,144 @@
+// Command gemlint lints gemtext files, exiting with a non-zero status
+// if problems are found.
+//
+// Usage:
+//
+//	gemlint [-disable GT004,...] [-enable ...] [-strict] [file ...]
+//
+// If no files are given, stdin is linted.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aalbacetef/libgemini/gemtext/lint"
+)
+
+const (
+	exitOK       = 0
+	exitProblems = 1
+	exitUsage    = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gemlint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	enable := ""
+	disable := ""
+	strict := false
+	listRules := false
+
+	flags.StringVar(&enable, "enable", enable, "comma-separated list of rule IDs or names to run (default: all)")
+	flags.StringVar(&disable, "disable", disable, "comma-separated list of rule IDs or names to skip")
+	flags.BoolVar(&strict, "strict", strict, "exit non-zero on warnings too")
+	flags.BoolVar(&listRules, "rules", listRules, "list the available rules and exit")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if listRules {
+		for _, rule := range lint.Rules() {
+			fmt.Fprintf(stdout, "%s  %-20s %-8s %s\n", rule.ID, rule.Name, rule.Severity, rule.Description)
+		}
+
+		return exitOK
+	}
+
+	cfg := lint.Config{}
+
+	var err error
+
+	if cfg.Enable, err = parseRules(enable); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+
+		return exitUsage
+	}
+
+	if cfg.Disable, err = parseRules(disable); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+
+		return exitUsage
+	}
+
+	minSeverity := lint.Error
+	if strict {
+		minSeverity = lint.Warning
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		return lintReader("<stdin>", stdin, cfg, minSeverity, stdout, stderr)
+	}
+
+	status := exitOK
+
+	for _, fpath := range files {
+		fileStatus := lintFile(fpath, cfg, minSeverity, stdout, stderr)
+		status = max(status, fileStatus)
+	}
+
+	return status
+}
+
+func parseRules(list string) ([]lint.RuleID, error) {
+	ids := make([]lint.RuleID, 0)
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		rule, found := lint.LookupRule(name)
+		if !found {
+			return nil, fmt.Errorf("unknown rule '%s'", name)
+		}
+
+		ids = append(ids, rule.ID)
+	}
+
+	return ids, nil
+}
+
+func lintFile(fpath string, cfg lint.Config, minSeverity lint.Severity, stdout, stderr io.Writer) int {
+	fd, err := os.Open(fpath)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+
+		return exitUsage
+	}
+	defer fd.Close()
+
+	return lintReader(fpath, fd, cfg, minSeverity, stdout, stderr)
+}
+
+func lintReader(name string, r io.Reader, cfg lint.Config, minSeverity lint.Severity, stdout, stderr io.Writer) int {
+	diags, err := lint.Lint(r, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s: %v\n", name, err)
+
+		return exitUsage
+	}
+
+	status := exitOK
+
+	for _, diag := range diags {
+		fmt.Fprintf(stdout, "%s:%s\n", name, diag)
+
+		if diag.Severity >= minSeverity {
+			status = exitProblems
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	const (
+		clean   = "# Heading\n=> gemini://example.com/ link\ntext\n"
+		warning = "#Heading\n"
+		problem = "=>\n"
+	)
+
+	dir := t.TempDir()
+
+	cleanFile := filepath.Join(dir, "clean.gmi")
+	problemFile := filepath.Join(dir, "problem.gmi")
+
+	for fpath, contents := range map[string]string{cleanFile: clean, problemFile: problem} {
+		if err := os.WriteFile(fpath, []byte(contents), 0o644); err != nil { //nolint:gosec
+			t.Fatalf("could not write '%s': %v", fpath, err)
+		}
+	}
+
+	cases := []struct {
+		label  string
+		args   []string
+		stdin  string
+		status int
+		stdout string
+	}{
+		{"clean input", nil, clean, exitOK, ""},
+		{"errors", nil, problem, exitProblems, "<stdin>:1: error [GT002]"},
+		{"warnings only", nil, warning, exitOK, "<stdin>:1: warning [GT003]"},
+		{"strict promotes warnings", []string{"-strict"}, warning, exitProblems, "[GT003]"},
+		{"disable by ID", []string{"-disable", "GT002"}, problem, exitOK, ""},
+		{"disable by name", []string{"-disable", "invalid-link-url"}, problem, exitOK, ""},
+		{"enable other rules", []string{"-enable", "GT003"}, problem, exitOK, ""},
+		{"unknown disabled rule", []string{"-disable", "GT999"}, clean, exitUsage, ""},
+		{"unknown enabled rule", []string{"-enable", "no-such-rule"}, clean, exitUsage, ""},
+		{"unknown flag", []string{"-nope"}, clean, exitUsage, ""},
+		{"clean file", []string{cleanFile}, "", exitOK, ""},
+		{"file with errors", []string{cleanFile, problemFile}, "", exitProblems, problemFile + ":1:"},
+		{"missing file", []string{filepath.Join(dir, "missing.gmi")}, "", exitUsage, ""},
+		{"list rules", []string{"-rules"}, "", exitOK, "GT001"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(tt *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+
+			status := run(c.args, strings.NewReader(c.stdin), stdout, stderr)
+			if status != c.status {
+				tt.Fatalf("got exit status %d, want %d\nstdout: %s\nstderr: %s", status, c.status, stdout, stderr)
+			}
+
+			if c.stdout == "" && c.status == exitOK && stdout.Len() != 0 {
+				tt.Errorf("expected no output, got '%s'", stdout)
+			}
+
+			if !strings.Contains(stdout.String(), c.stdout) {
+				tt.Errorf("expected '%s' in output, got '%s'", c.stdout, stdout)
+			}
+
+			if c.status == exitUsage && stderr.Len() == 0 {
+				tt.Errorf("expected usage errors on stderr")
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	ID        string     `xml:"id"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published,omitempty"`
+	Links     []atomLink `xml:"link"`
+}
+
+// ParseAtom will parse an Atom feed. Entry URLs are taken from their
+// alternate link, falling back to the entry ID, and are resolved against
+// base. Entry dates use the published date, falling back to updated.
+func ParseAtom(base *url.URL, r io.Reader) (Feed, error) {
+	af := atomFeed{}
+	if err := xml.NewDecoder(r).Decode(&af); err != nil {
+		return Feed{}, fmt.Errorf("could not decode atom feed: %w", err)
+	}
+
+	f := Feed{
+		Title:    strings.TrimSpace(af.Title),
+		Subtitle: strings.TrimSpace(af.Subtitle),
+		URL:      resolve(base, alternateLink(af.Links, af.ID)),
+		Updated:  parseAtomDate(af.Updated),
+	}
+
+	if f.URL == nil {
+		f.URL = base
+	}
+
+	for _, ae := range af.Entries {
+		date := parseAtomDate(ae.Published)
+		if date.IsZero() {
+			date = parseAtomDate(ae.Updated)
+		}
+
+		f.Entries = append(f.Entries, Entry{
+			Date:  date,
+			Title: strings.TrimSpace(ae.Title),
+			URL:   resolve(base, alternateLink(ae.Links, ae.ID)),
+		})
+	}
+
+	f.Sort()
+
+	return f, nil
+}
+
+func alternateLink(links []atomLink, fallback string) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+
+	return strings.TrimSpace(fallback)
+}
+
+func resolve(base *url.URL, ref string) *url.URL {
+	if ref == "" {
+		return nil
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil
+	}
+
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	return u
+}
+
+func parseAtomDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+
+	for _, layout := range []string{time.RFC3339, DateLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// WriteAtom will write the feed as an Atom document to w. The feed's
+// URL is used as its ID, so it should be set.
+func WriteAtom(w io.Writer, f Feed) error {
+	af := atomFeed{
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+	}
+
+	if f.URL != nil {
+		af.ID = f.URL.String()
+		af.Links = []atomLink{{Href: af.ID, Rel: "alternate"}}
+	}
+
+	for _, entry := range f.Entries {
+		href := ""
+		if entry.URL != nil {
+			href = entry.URL.String()
+		}
+
+		date := entry.Date.UTC().Format(time.RFC3339)
+
+		af.Entries = append(af.Entries, atomEntry{
+			Title:     entry.Title,
+			ID:        href,
+			Updated:   date,
+			Published: date,
+			Links:     []atomLink{{Href: href, Rel: "alternate"}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("could not write atom feed: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(af); err != nil {
+		return fmt.Errorf("could not encode atom feed: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("could not write atom feed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package feed implements parsing and generation of Gemini subscriptions,
+// both gemtext pages following the gemfeed convention and Atom feeds.
+package feed
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aalbacetef/libgemini/gemtext"
+)
+
+const subtitleLevel = 2
+
+// DateLayout is the layout of the date prefixing each entry's link label.
+const DateLayout = "2006-01-02"
+
+const (
+	AtomMIME = "application/atom+xml"
+	XMLMIME  = "application/xml"
+)
+
+// Entry is a single post of a feed.
+type Entry struct {
+	Date  time.Time
+	Title string
+	URL   *url.URL
+}
+
+// Feed is a parsed subscription.
+type Feed struct {
+	Title    string
+	Subtitle string
+	URL      *url.URL
+	Updated  time.Time
+	Entries  []Entry
+}
+
+// Sort orders the entries from newest to oldest and sets Updated to the
+// date of the newest entry, if it is not already set.
+func (f *Feed) Sort() {
+	sort.SliceStable(f.Entries, func(i, j int) bool {
+		return f.Entries[i].Date.After(f.Entries[j].Date)
+	})
+
+	if f.Updated.IsZero() && len(f.Entries) > 0 {
+		f.Updated = f.Entries[0].Date
+	}
+}
+
+// Parse will parse r based on the provided MIME type, dispatching to
+// ParseGemfeed or ParseAtom. Relative URLs are resolved against base.
+func Parse(base *url.URL, mime string, r io.Reader) (Feed, error) {
+	mediaType, _, _ := strings.Cut(mime, ";")
+
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case gemtext.MIME:
+		return ParseGemfeed(base, r)
+	case AtomMIME, XMLMIME, "text/xml":
+		return ParseAtom(base, r)
+	default:
+		return Feed{}, fmt.Errorf("unsupported feed type '%s'", mime)
+	}
+}
+
+// ParseGemfeed will parse a gemtext page following the Gemini subscription
+// convention: the first level-1 heading is the title, a level-2 heading
+// directly after it is the subtitle, and every link line whose label starts
+// with a YYYY-MM-DD date is an entry.
+func ParseGemfeed(base *url.URL, r io.Reader) (Feed, error) {
+	doc, err := gemtext.Parse(r)
+	if err != nil {
+		return Feed{}, fmt.Errorf("could not parse gemfeed: %w", err)
+	}
+
+	f := Feed{URL: base}
+	afterTitle := false
+
+	for _, line := range doc.Lines {
+		if line.Type == gemtext.TextLine && strings.TrimSpace(line.Text) == "" {
+			continue
+		}
+
+		if line.Type == gemtext.HeadingLine && line.Level == 1 && f.Title == "" {
+			f.Title = line.Text
+			afterTitle = true
+
+			continue
+		}
+
+		if afterTitle && line.Type == gemtext.HeadingLine && line.Level == subtitleLevel {
+			f.Subtitle = line.Text
+		}
+
+		if line.Type == gemtext.LinkLine {
+			if entry, ok := parseEntry(base, line); ok {
+				f.Entries = append(f.Entries, entry)
+			}
+		}
+
+		afterTitle = false
+	}
+
+	f.Sort()
+
+	return f, nil
+}
+
+func parseEntry(base *url.URL, line gemtext.Line) (Entry, bool) {
+	if len(line.Text) < len(DateLayout) {
+		return Entry{}, false
+	}
+
+	date, err := time.Parse(DateLayout, line.Text[:len(DateLayout)])
+	if err != nil {
+		return Entry{}, false
+	}
+
+	u, err := url.Parse(line.URL)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	title := strings.TrimSpace(line.Text[len(DateLayout):])
+	title = strings.TrimSpace(strings.TrimLeft(title, "-–—:"))
+
+	return Entry{Date: date, Title: title, URL: u}, true
+}
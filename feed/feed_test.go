@@ -0,0 +1,110 @@
+package feed
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testGemfeed = `# My Gemlog
+## Thoughts and such
+
+=> 2024-01-05-second.gmi 2024-01-05 - Second post
+=> /about.gmi About me
+=> 2024-01-01-first.gmi 2024-01-01 First post
+`
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("could not parse URL: %v", err)
+	}
+
+	return u
+}
+
+func TestParseGemfeed(t *testing.T) {
+	base := mustParseURL(t, "gemini://example.com/gemlog/")
+
+	f, err := ParseGemfeed(base, strings.NewReader(testGemfeed))
+	if err != nil {
+		t.Fatalf("could not parse gemfeed: %v", err)
+	}
+
+	if f.Title != "My Gemlog" || f.Subtitle != "Thoughts and such" {
+		t.Fatalf("got title %q and subtitle %q", f.Title, f.Subtitle)
+	}
+
+	want := []struct {
+		date  string
+		title string
+		url   string
+	}{
+		{"2024-01-05", "Second post", "gemini://example.com/gemlog/2024-01-05-second.gmi"},
+		{"2024-01-01", "First post", "gemini://example.com/gemlog/2024-01-01-first.gmi"},
+	}
+
+	if got, n := len(f.Entries), len(want); got != n {
+		t.Fatalf("got %d entries, want %d", got, n)
+	}
+
+	for k, w := range want {
+		entry := f.Entries[k]
+		got := entry.Date.Format(DateLayout)
+
+		if got != w.date || entry.Title != w.title || entry.URL.String() != w.url {
+			t.Fatalf("(entry %d) got (%s, %q, %s), want (%s, %q, %s)", k, got, entry.Title, entry.URL, w.date, w.title, w.url)
+		}
+	}
+}
+
+func TestAtomRoundTrip(t *testing.T) {
+	base := mustParseURL(t, "gemini://example.com/gemlog/")
+
+	fsys := fstest.MapFS{
+		"gemlog/2024-01-01-first.gmi":         {Data: []byte("# First post\n\nHello.\n")},
+		"gemlog/2024-01-05-untitled-post.gmi": {Data: []byte("No heading here.\n")},
+		"gemlog/index.gmi":                    {Data: []byte("# Index\n")},
+	}
+
+	f, err := FromDir(fsys, "gemlog", base)
+	if err != nil {
+		t.Fatalf("could not build feed: %v", err)
+	}
+
+	f.Title = "My Gemlog"
+
+	buf := &bytes.Buffer{}
+	if err := WriteAtom(buf, f); err != nil {
+		t.Fatalf("could not write atom: %v", err)
+	}
+
+	parsed, err := Parse(nil, "application/atom+xml", buf)
+	if err != nil {
+		t.Fatalf("could not parse atom: %v", err)
+	}
+
+	if parsed.Title != f.Title || len(parsed.Entries) != 2 {
+		t.Fatalf("unexpected feed: %+v", parsed)
+	}
+
+	first := parsed.Entries[0]
+	if first.Title != "untitled post" || first.URL.String() != "gemini://example.com/gemlog/2024-01-05-untitled-post.gmi" {
+		t.Fatalf("unexpected entry: %+v", first)
+	}
+
+	buf.Reset()
+
+	if err := WriteGemfeed(buf, f); err != nil {
+		t.Fatalf("could not write gemfeed: %v", err)
+	}
+
+	want := "=> gemini://example.com/gemlog/2024-01-01-first.gmi 2024-01-01 - First post\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Fatalf("gemfeed missing entry, got:\n%s", buf.String())
+	}
+}
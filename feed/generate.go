@@ -0,0 +1,121 @@
+package feed
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aalbacetef/libgemini/gemtext"
+)
+
+// FromDir will build a feed from the posts found in dir. Posts are the
+// gemtext files whose name starts with a YYYY-MM-DD date, e.g.
+// 2024-10-20-hello-world.gmi. The title of each post is its first level-1
+// heading, falling back to the rest of the file name. Entry URLs are the
+// file names resolved against base, which should point at dir.
+//
+// The returned feed has no title, callers are expected to set it.
+func FromDir(fsys fs.FS, dir string, base *url.URL) (Feed, error) {
+	dirEntries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return Feed{}, fmt.Errorf("could not read directory '%s': %w", dir, err)
+	}
+
+	f := Feed{URL: base}
+
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !isPost(name) {
+			continue
+		}
+
+		date, err := time.Parse(DateLayout, name[:len(DateLayout)])
+		if err != nil {
+			continue
+		}
+
+		title, err := postTitle(fsys, path.Join(dir, name))
+		if err != nil {
+			return Feed{}, err
+		}
+
+		if title == "" {
+			title = strings.TrimSuffix(name[len(DateLayout):], path.Ext(name))
+			title = strings.ReplaceAll(strings.Trim(title, "-_"), "-", " ")
+		}
+
+		f.Entries = append(f.Entries, Entry{
+			Date:  date,
+			Title: title,
+			URL:   resolve(base, (&url.URL{Path: name}).String()),
+		})
+	}
+
+	f.Sort()
+
+	return f, nil
+}
+
+func isPost(name string) bool {
+	ext := path.Ext(name)
+	if ext != ".gmi" && ext != ".gemini" {
+		return false
+	}
+
+	return len(name) > len(DateLayout)
+}
+
+func postTitle(fsys fs.FS, fpath string) (string, error) {
+	fd, err := fsys.Open(fpath)
+	if err != nil {
+		return "", fmt.Errorf("could not open post '%s': %w", fpath, err)
+	}
+	defer fd.Close()
+
+	scanner := gemtext.NewScanner(fd)
+	for scanner.Scan() {
+		line := scanner.Line()
+		if line.Type == gemtext.HeadingLine && line.Level == 1 {
+			return line.Text, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("could not read post '%s': %w", fpath, err)
+	}
+
+	return "", nil
+}
+
+// WriteGemfeed will write the feed as a gemtext page following the Gemini
+// subscription convention.
+func WriteGemfeed(w io.Writer, f Feed) error {
+	bdr := &strings.Builder{}
+
+	fmt.Fprintf(bdr, "# %s\n", f.Title)
+
+	if f.Subtitle != "" {
+		fmt.Fprintf(bdr, "## %s\n", f.Subtitle)
+	}
+
+	bdr.WriteString("\n")
+
+	for _, entry := range f.Entries {
+		href := ""
+		if entry.URL != nil {
+			href = entry.URL.String()
+		}
+
+		fmt.Fprintf(bdr, "=> %s %s - %s\n", href, entry.Date.Format(DateLayout), entry.Title)
+	}
+
+	if _, err := io.WriteString(w, bdr.String()); err != nil {
+		return fmt.Errorf("could not write gemfeed: %w", err)
+	}
+
+	return nil
+}
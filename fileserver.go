@@ -0,0 +1,235 @@
+package libgemini
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// IndexFile is served by FileServer for directories containing it.
+	IndexFile   = "index.gmi"
+	octetStream = "application/octet-stream"
+)
+
+// FileServer returns a handler serving the files in fsys, using the
+// request's path as the file name.
+//
+// Files are served with a MIME type inferred from their extension, with
+// .gmi and .gemini files served as text/gemini. Directories are served
+// using their index.gmi file if present, otherwise a gemtext listing is
+// generated. Paths containing ".." are refused with BadRequest, and
+// dotfiles are answered with NotFound.
+//
+// To serve an embed.FS, use fs.Sub to strip the embedded directory name,
+// and StripPrefix to serve it under a path other than the root.
+func FileServer(fsys fs.FS) Handler {
+	return &fileHandler{fsys: fsys}
+}
+
+type fileHandler struct {
+	fsys fs.FS
+}
+
+func (fh *fileHandler) ServeGemini(w ResponseWriter, r *Request) {
+	upath := r.u.Path
+	if upath == "" {
+		upath = "/"
+	}
+
+	if containsDotDot(upath) {
+		Error(w, BadRequest, "invalid path")
+
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean(upath), "/")
+	if name == "" {
+		name = "."
+	}
+
+	if !fs.ValidPath(name) || isDotfile(name) {
+		Error(w, NotFound, "not found")
+
+		return
+	}
+
+	info, err := fs.Stat(fh.fsys, name)
+	if err != nil {
+		fh.serveError(w, err)
+
+		return
+	}
+
+	if !info.IsDir() {
+		if strings.HasSuffix(upath, "/") {
+			redirectRelative(w, "../"+escapeName(path.Base(upath)))
+
+			return
+		}
+
+		fh.serveFile(w, name)
+
+		return
+	}
+
+	if !strings.HasSuffix(upath, "/") {
+		redirectRelative(w, "./"+escapeName(path.Base(upath))+"/")
+
+		return
+	}
+
+	index := path.Join(name, IndexFile)
+	if _, err := fs.Stat(fh.fsys, index); err == nil {
+		fh.serveFile(w, index)
+
+		return
+	}
+
+	fh.serveDir(w, upath, name)
+}
+
+func (fh *fileHandler) serveError(w ResponseWriter, err error) {
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		Error(w, NotFound, "not found")
+
+		return
+	}
+
+	Error(w, TemporaryFailure, "could not read file")
+}
+
+func (fh *fileHandler) serveFile(w ResponseWriter, name string) {
+	fd, err := fh.fsys.Open(name)
+	if err != nil {
+		fh.serveError(w, err)
+
+		return
+	}
+	defer fd.Close()
+
+	w.WriteHeader(Success, mimeTypeByExtension(name))
+
+	_, _ = io.Copy(w, fd) //nolint:errcheck
+}
+
+func (fh *fileHandler) serveDir(w ResponseWriter, upath, name string) {
+	entries, err := fs.ReadDir(fh.fsys, name)
+	if err != nil {
+		fh.serveError(w, err)
+
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	bdr := &strings.Builder{}
+	fmt.Fprintf(bdr, "# Index of %s\n\n", upath)
+
+	if upath != "/" {
+		bdr.WriteString("=> ../ ..\n")
+	}
+
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if strings.HasPrefix(entryName, ".") {
+			continue
+		}
+
+		if entry.IsDir() {
+			entryName += "/"
+		}
+
+		// NOTE: the ./ prefix stops names containing a colon being parsed as a scheme.
+		href := "./" + escapeName(entryName)
+		fmt.Fprintf(bdr, "=> %s %s\n", href, entryName)
+	}
+
+	w.WriteHeader(Success, DefaultMIME)
+
+	_, _ = io.WriteString(w, bdr.String()) //nolint:errcheck
+}
+
+// redirectRelative redirects using a relative target, so redirects keep
+// working when the handler is wrapped with StripPrefix.
+func redirectRelative(w ResponseWriter, target string) {
+	Redirect(w, target, RedirectPermanent)
+}
+
+func escapeName(name string) string {
+	return (&url.URL{Path: name}).EscapedPath()
+}
+
+func containsDotDot(upath string) bool {
+	for _, seg := range strings.Split(upath, "/") {
+		if seg == ".." {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDotfile(name string) bool {
+	for _, seg := range strings.Split(name, "/") {
+		if strings.HasPrefix(seg, ".") && seg != "." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mimeTypeByExtension returns the MIME type for name based on its
+// extension, defaulting to application/octet-stream.
+func mimeTypeByExtension(name string) string {
+	ext := strings.ToLower(path.Ext(name))
+
+	switch ext {
+	case ".gmi", ".gemini":
+		return DefaultMIME
+	case "":
+		return octetStream
+	}
+
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType
+	}
+
+	return octetStream
+}
+
+// StripPrefix returns a handler serving requests by removing prefix from
+// the request's path and invoking h. Requests whose path does not start
+// with prefix are answered with NotFound.
+func StripPrefix(prefix string, h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		rest, found := strings.CutPrefix(r.u.Path, prefix)
+		if !found {
+			Error(w, NotFound, "not found")
+
+			return
+		}
+
+		u := r.URL()
+		u.Path = rest
+		u.RawPath = ""
+
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Path = "/" + u.Path
+		}
+
+		r2 := *r
+		r2.u = u
+
+		h.ServeGemini(w, &r2)
+	})
+}
@@ -0,0 +1,57 @@
+package libgemini
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileServer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.gmi":          {Data: []byte("# Home\n")},
+		"notes.txt":          {Data: []byte("plain")},
+		"gemlog/post.gmi":    {Data: []byte("# Post\n")},
+		"gemlog/.secret.gmi": {Data: []byte("hidden")},
+		".env":               {Data: []byte("SECRET=1")},
+	}
+
+	handler := FileServer(fsys)
+
+	cases := []struct {
+		url    string
+		status StatusCode
+		meta   string
+		body   string
+	}{
+		{"gemini://example.com/", Success, "text/gemini", "# Home\n"},
+		{"gemini://example.com", Success, "text/gemini", "# Home\n"},
+		{"gemini://example.com/notes.txt", Success, "text/plain; charset=utf-8", "plain"},
+		{"gemini://example.com/gemlog", RedirectPermanent, "./gemlog/", ""},
+		{"gemini://example.com/gemlog/post.gmi/", RedirectPermanent, "../post.gmi", ""},
+		{"gemini://example.com/gemlog/", Success, "text/gemini", "# Index of /gemlog/\n\n=> ../ ..\n=> ./post.gmi post.gmi\n"},
+		{"gemini://example.com/missing.gmi", NotFound, "not found", ""},
+		{"gemini://example.com/.env", NotFound, "not found", ""},
+		{"gemini://example.com/gemlog/.secret.gmi", NotFound, "not found", ""},
+		{"gemini://example.com/gemlog/../.env", BadRequest, "invalid path", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(tt *testing.T) {
+			rec := &recorder{}
+			handler.ServeGemini(rec, mustRequest(tt, c.url))
+
+			if rec.status != c.status || rec.meta != c.meta || rec.body.String() != c.body {
+				tt.Fatalf("got (%d, %q, %q), want (%d, %q, %q)", rec.status, rec.meta, rec.body.String(), c.status, c.meta, c.body)
+			}
+		})
+	}
+
+	t.Run("it works with StripPrefix", func(tt *testing.T) {
+		rec := &recorder{}
+		StripPrefix("/static", handler).ServeGemini(rec, mustRequest(tt, "gemini://example.com/static/gemlog/post.gmi"))
+
+		if rec.status != Success || !strings.HasPrefix(rec.body.String(), "# Post") {
+			tt.Fatalf("got (%d, %q)", rec.status, rec.body.String())
+		}
+	})
+}
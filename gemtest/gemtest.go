@@ -0,0 +1,37 @@
+// Package gemtest provides utilities for testing Gemini clients and
+// handlers, in the spirit of net/http/httptest.
+package gemtest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aalbacetef/libgemini"
+)
+
+// DefaultRemoteAddr is the RemoteAddr of requests returned by NewRequest.
+const DefaultRemoteAddr = "192.0.2.1:1234"
+
+// NewRequest returns a server request for target, suitable for passing to
+// a Handler. It panics if target is not a valid request.
+//
+// For titan:// targets, body is the upload's content; it is ignored for
+// other requests and may be nil.
+func NewRequest(target string, body io.Reader) *libgemini.Request {
+	if body == nil {
+		body = strings.NewReader("")
+	}
+
+	r := bufio.NewReader(io.MultiReader(strings.NewReader(target+libgemini.CRLF), body))
+
+	req, err := libgemini.ReadRequest(r)
+	if err != nil {
+		panic(fmt.Sprintf("gemtest: invalid request '%s': %v", target, err))
+	}
+
+	req.RemoteAddr = DefaultRemoteAddr
+
+	return req
+}
@@ -0,0 +1,108 @@
+package gemtest
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aalbacetef/libgemini"
+)
+
+func hello(w libgemini.ResponseWriter, r *libgemini.Request) {
+	if r.URL().RawQuery == "" {
+		w.WriteHeader(libgemini.Input, "name?")
+
+		return
+	}
+
+	w.Write([]byte("hello " + r.URL().RawQuery))
+}
+
+func TestServer(t *testing.T) {
+	// NOTE: the client must ignore the user's configuration.
+	rcFile := filepath.Join(t.TempDir(), "geminirc")
+	t.Setenv(libgemini.EnvRC, rcFile)
+	t.Setenv(libgemini.EnvRecord, filepath.Join(t.TempDir(), "cassette.json"))
+
+	srv := NewServer(libgemini.HandlerFunc(hello))
+	defer srv.Close()
+
+	resp, err := srv.Client.Get(srv.URL + "/?gemini")
+	if err != nil {
+		t.Fatalf("could not get: %v", err)
+	}
+
+	if _, err := os.Stat(rcFile); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the client must not create a geminirc, got: %v", err)
+	}
+
+	if srv.Client.RecordPath != "" {
+		t.Errorf("the client must not read the environment, records to '%s'", srv.Client.RecordPath)
+	}
+
+	if resp.Header.Status != libgemini.Success || string(resp.Content) != "hello gemini" {
+		t.Errorf("unexpected response: %+v, '%s'", resp.Header, resp.Content)
+	}
+
+	other := NewServer(libgemini.HandlerFunc(hello))
+	defer other.Close()
+
+	if _, err := srv.Client.Get(other.URL + "/?gemini"); err == nil {
+		t.Errorf("expected the client to refuse another server's certificate")
+	}
+}
+
+func TestResponseRecorder(t *testing.T) {
+	rec := NewRecorder()
+	hello(rec, NewRequest("gemini://example.com/?you", nil))
+
+	resp := rec.Result()
+	if resp.Header.Status != libgemini.Success || resp.Header.Meta != libgemini.DefaultMIME || string(resp.Content) != "hello you" {
+		t.Errorf("unexpected response: %+v, '%s'", resp.Header, resp.Content)
+	}
+
+	rec = NewRecorder()
+	hello(rec, NewRequest("gemini://example.com/", nil))
+
+	if rec.Status != libgemini.Input || rec.Meta != "name?" {
+		t.Errorf("got '%d %s'", rec.Status, rec.Meta)
+	}
+
+	if _, err := rec.Write([]byte("body")); !errors.Is(err, libgemini.ErrBodyNotAllowed) {
+		t.Errorf("expected ErrBodyNotAllowed, got %v", err)
+	}
+
+	if resp := NewRecorder().Result(); resp.Header.Status != libgemini.Success {
+		t.Errorf("expected an implicit success header, got %+v", resp.Header)
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	req := NewRequest("titan://example.com/a.gmi;size=5", strings.NewReader("hello"))
+
+	upload := req.Titan()
+	if upload == nil {
+		t.Fatalf("expected an upload")
+	}
+
+	body, err := io.ReadAll(upload.Body)
+	if err != nil || string(body) != "hello" {
+		t.Errorf("got '%s', %v", body, err)
+	}
+
+	if req.RemoteAddr != DefaultRemoteAddr {
+		t.Errorf("got remote address '%s'", req.RemoteAddr)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for an invalid request")
+		}
+	}()
+
+	NewRequest("/relative", nil)
+}
@@ -0,0 +1,66 @@
+package gemtest
+
+import (
+	"bytes"
+
+	"github.com/aalbacetef/libgemini"
+)
+
+// ResponseRecorder is a libgemini.ResponseWriter recording what a handler
+// writes, for inspection in tests.
+type ResponseRecorder struct {
+	// Status and Meta are the header written by the handler. If the
+	// handler only wrote a body, they are set to the implicit header.
+	Status libgemini.StatusCode
+	Meta   string
+
+	// Body receives the response body.
+	Body *bytes.Buffer
+
+	// WroteHeader reports whether a header was written.
+	WroteHeader bool
+}
+
+// NewRecorder returns an initialized ResponseRecorder.
+func NewRecorder() *ResponseRecorder {
+	return &ResponseRecorder{Body: &bytes.Buffer{}}
+}
+
+// WriteHeader records the first header written, ignoring later calls.
+func (rec *ResponseRecorder) WriteHeader(status libgemini.StatusCode, meta string) {
+	if rec.WroteHeader {
+		return
+	}
+
+	rec.Status = status
+	rec.Meta = meta
+	rec.WroteHeader = true
+}
+
+// Write records p in Body, writing the implicit "20 text/gemini" header
+// first if needed. Like the server, it refuses bodies after a non-success
+// header.
+func (rec *ResponseRecorder) Write(p []byte) (int, error) {
+	if !rec.WroteHeader {
+		rec.WriteHeader(libgemini.Success, libgemini.DefaultMIME)
+	}
+
+	if !rec.Status.IsSuccess() {
+		return 0, libgemini.ErrBodyNotAllowed
+	}
+
+	return rec.Body.Write(p) //nolint:wrapcheck
+}
+
+// Result returns the recorded response, as a Client would have read it.
+func (rec *ResponseRecorder) Result() libgemini.Response {
+	status, meta := rec.Status, rec.Meta
+	if !rec.WroteHeader {
+		status, meta = libgemini.Success, libgemini.DefaultMIME
+	}
+
+	return libgemini.Response{
+		Header:  libgemini.Header{Status: status, Meta: meta},
+		Content: bytes.Clone(rec.Body.Bytes()),
+	}
+}
@@ -0,0 +1,132 @@
+package gemtest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/aalbacetef/libgemini"
+	"github.com/aalbacetef/tofu"
+)
+
+const (
+	// Hostname is the host of Server.URL, and the name its certificate is
+	// valid for.
+	Hostname = "localhost"
+
+	certificateValidity = 24 * time.Hour
+	knownHostsFile      = "known_hosts"
+)
+
+// Server is a Gemini server listening on a loopback address, for use in
+// end-to-end tests.
+type Server struct {
+	// URL is the base URL of the server, of the form gemini://localhost:port.
+	URL string
+
+	// Listener is the listener the server accepts connections on.
+	Listener net.Listener
+
+	// Certificate is the self-signed certificate presented by the server.
+	Certificate tls.Certificate
+
+	// Config is the underlying server.
+	Config *libgemini.Server
+
+	// Client trusts the server's certificate, and only that certificate
+	// for Hostname. It uses a known hosts file private to the server.
+	Client *libgemini.Client
+
+	dir string
+}
+
+// NewServer starts and returns a new Server serving handler. The caller
+// should call Close when finished, to shut it down. It panics if the
+// server cannot be started.
+func NewServer(handler libgemini.Handler) *Server {
+	srv, err := newServer(handler)
+	if err != nil {
+		panic(fmt.Sprintf("gemtest: could not start server: %v", err))
+	}
+
+	return srv
+}
+
+func newServer(handler libgemini.Handler) (*Server, error) {
+	cert, err := libgemini.GenerateCertificate(libgemini.CertificateOptions{
+		Hosts:    []string{Hostname, "127.0.0.1"},
+		Validity: certificateValidity,
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	dir, err := os.MkdirTemp("", "gemtest")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+
+	client, err := trustingClient(filepath.Join(dir, knownHostsFile), cert)
+	if err != nil {
+		os.RemoveAll(dir)
+
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.RemoveAll(dir)
+
+		return nil, fmt.Errorf("could not listen: %w", err)
+	}
+
+	config := &libgemini.Server{
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		},
+	}
+
+	go config.Serve(l) //nolint:errcheck
+
+	port := strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+
+	srv := &Server{
+		URL:         "gemini://" + net.JoinHostPort(Hostname, port),
+		Listener:    l,
+		Certificate: cert,
+		Config:      config,
+		Client:      client,
+		dir:         dir,
+	}
+
+	return srv, nil
+}
+
+// trustingClient returns a client whose known hosts file, at fpath, only
+// holds cert's fingerprint for Hostname. The user's geminirc and
+// environment are ignored, so that they can't affect tests.
+func trustingClient(fpath string, cert tls.Certificate) (*libgemini.Client, error) {
+	store, err := tofu.NewFileStore(fpath)
+	if err != nil {
+		return nil, fmt.Errorf("could not create known hosts file: %w", err)
+	}
+
+	host := tofu.Host{Address: Hostname, Fingerprint: tofu.Fingerprint(cert.Leaf)}
+	if err := store.Add(host); err != nil {
+		return nil, fmt.Errorf("could not add host: %w", err)
+	}
+
+	return libgemini.NewClient(libgemini.WithoutConfig(), libgemini.WithStore(fpath))
+}
+
+// Close shuts down the server and removes its known hosts file.
+func (srv *Server) Close() {
+	_ = srv.Config.Close()    //nolint:errcheck
+	_ = os.RemoveAll(srv.dir) //nolint:errcheck
+}
@@ -0,0 +1,169 @@
+// Package gemtext implements a parser for the text/gemini media type.
+package gemtext
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MIME is the media type of gemtext documents.
+const MIME = "text/gemini"
+
+// LineType identifies the kind of a gemtext line.
+type LineType int
+
+const (
+	TextLine LineType = iota
+	LinkLine
+	PreformatToggleLine
+	PreformattedTextLine
+	HeadingLine
+	ListItemLine
+	QuoteLine
+)
+
+func (lt LineType) String() string {
+	switch lt {
+	case TextLine:
+		return "text"
+	case LinkLine:
+		return "link"
+	case PreformatToggleLine:
+		return "preformat-toggle"
+	case PreformattedTextLine:
+		return "preformatted"
+	case HeadingLine:
+		return "heading"
+	case ListItemLine:
+		return "list-item"
+	case QuoteLine:
+		return "quote"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	linkPrefix      = "=>"
+	togglePrefix    = "```"
+	headingPrefix   = "#"
+	listItemPrefix  = "* "
+	quotePrefix     = ">"
+	maxHeadingLevel = 3
+)
+
+// Line is a single parsed gemtext line.
+//
+// Raw holds the line as found in the document, without its terminator.
+// Text holds the content of the line: the label of a link, the text of a
+// heading, list item or quote, or the alt text of a preformat toggle.
+// Truncated is set by a Scanner when the line exceeded its MaxLineSize.
+type Line struct {
+	Type      LineType
+	Raw       string
+	Text      string
+	URL       string
+	Level     int
+	Number    int
+	Truncated bool
+}
+
+// Document is a parsed gemtext document.
+type Document struct {
+	Lines []Line
+}
+
+// String renders the document back to gemtext, joining lines with LF.
+func (doc Document) String() string {
+	bdr := &strings.Builder{}
+
+	for _, line := range doc.Lines {
+		bdr.WriteString(line.Raw)
+		bdr.WriteByte('\n')
+	}
+
+	return bdr.String()
+}
+
+// Parse will read all of r and parse it as a gemtext document. Both LF
+// and CRLF line terminators are accepted. Lines are never truncated.
+func Parse(r io.Reader) (Document, error) {
+	doc := Document{}
+
+	scanner := NewScanner(r)
+	scanner.MaxLineSize = 0
+
+	for scanner.Scan() {
+		doc.Lines = append(doc.Lines, scanner.Line())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return doc, fmt.Errorf("error reading document: %w", err)
+	}
+
+	return doc, nil
+}
+
+// ParseBytes parses data as a gemtext document.
+func ParseBytes(data []byte) Document {
+	// NOTE: reading from a bytes.Reader cannot fail.
+	doc, _ := Parse(bytes.NewReader(data)) //nolint:errcheck
+
+	return doc
+}
+
+// ParseLine parses a single line, without its terminator. Since the meaning
+// of a line depends on whether it is inside a preformatted block, the caller
+// must track the toggle state.
+func ParseLine(raw string, preformatted bool) Line {
+	line := Line{Raw: raw}
+
+	switch {
+	case strings.HasPrefix(raw, togglePrefix):
+		line.Type = PreformatToggleLine
+		line.Text = strings.TrimSpace(raw[len(togglePrefix):])
+	case preformatted:
+		line.Type = PreformattedTextLine
+		line.Text = raw
+	case strings.HasPrefix(raw, linkPrefix):
+		line.Type = LinkLine
+		line.URL, line.Text = parseLink(raw[len(linkPrefix):])
+	case strings.HasPrefix(raw, headingPrefix):
+		line.Type = HeadingLine
+		line.Level, line.Text = parseHeading(raw)
+	case strings.HasPrefix(raw, listItemPrefix):
+		line.Type = ListItemLine
+		line.Text = strings.TrimSpace(raw[len(listItemPrefix):])
+	case strings.HasPrefix(raw, quotePrefix):
+		line.Type = QuoteLine
+		line.Text = strings.TrimSpace(raw[len(quotePrefix):])
+	default:
+		line.Type = TextLine
+		line.Text = raw
+	}
+
+	return line
+}
+
+// parseLink splits the remainder of a link line into its URL and label.
+func parseLink(s string) (string, string) {
+	s = strings.TrimLeft(s, " \t")
+
+	index := strings.IndexAny(s, " \t")
+	if index == -1 {
+		return s, ""
+	}
+
+	return s[:index], strings.TrimSpace(s[index:])
+}
+
+func parseHeading(raw string) (int, string) {
+	level := 0
+	for level < len(raw) && level < maxHeadingLevel && raw[level] == '#' {
+		level++
+	}
+
+	return level, strings.TrimSpace(raw[level:])
+}
@@ -0,0 +1,60 @@
+package gemtext
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	doc := ParseBytes([]byte("# Title\r\n=> gemini://example.com Example\n```alt\n=> not a link\n```\n* item\n> quote\ntext\n"))
+
+	want := []struct {
+		typ  LineType
+		text string
+		url  string
+	}{
+		{HeadingLine, "Title", ""},
+		{LinkLine, "Example", "gemini://example.com"},
+		{PreformatToggleLine, "alt", ""},
+		{PreformattedTextLine, "=> not a link", ""},
+		{PreformatToggleLine, "", ""},
+		{ListItemLine, "item", ""},
+		{QuoteLine, "quote", ""},
+		{TextLine, "text", ""},
+	}
+
+	if got, n := len(doc.Lines), len(want); got != n {
+		t.Fatalf("got %d lines, want %d", got, n)
+	}
+
+	for k, w := range want {
+		line := doc.Lines[k]
+
+		if line.Type != w.typ || line.Text != w.text || line.URL != w.url {
+			t.Fatalf("(line %d) got %s %q %q, want %s %q %q", k+1, line.Type, line.Text, line.URL, w.typ, w.text, w.url)
+		}
+
+		if line.Number != k+1 {
+			t.Fatalf("(line %d) got line number %d", k+1, line.Number)
+		}
+	}
+}
+
+func TestParseLink(t *testing.T) {
+	cases := []struct {
+		raw   string
+		url   string
+		label string
+	}{
+		{"=>/foo", "/foo", ""},
+		{"=> /foo", "/foo", ""},
+		{"=>\t/foo \t bar baz ", "/foo", "bar baz"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.raw, func(tt *testing.T) {
+			line := ParseLine(c.raw, false)
+
+			if line.URL != c.url || line.Text != c.label {
+				tt.Fatalf("got (%q, %q), want (%q, %q)", line.URL, line.Text, c.url, c.label)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Package lint checks gemtext documents for common mistakes and
+// deviations from the specification.
+package lint
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aalbacetef/libgemini/gemtext"
+)
+
+// Severity is how serious a diagnostic is.
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (sev Severity) String() string {
+	switch sev {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// RuleID is the stable identifier of a rule. IDs are never reused.
+type RuleID string
+
+const (
+	UnclosedPreformat  RuleID = "GT001"
+	InvalidLinkURL     RuleID = "GT002"
+	HeadingNoSpace     RuleID = "GT003"
+	TrailingWhitespace RuleID = "GT004"
+	ListItemNoSpace    RuleID = "GT005"
+)
+
+// Rule describes a check performed by the linter.
+type Rule struct {
+	ID          RuleID
+	Name        string
+	Severity    Severity
+	Description string
+}
+
+// Rules returns every rule known to the linter, ordered by ID.
+func Rules() []Rule {
+	return []Rule{
+		{UnclosedPreformat, "unclosed-preformat", Error, "preformatted block is never closed"},
+		{InvalidLinkURL, "invalid-link-url", Error, "link line has a missing or invalid URL"},
+		{HeadingNoSpace, "heading-no-space", Warning, "heading marker is not followed by a space"},
+		{TrailingWhitespace, "trailing-whitespace", Warning, "line ends with whitespace"},
+		{ListItemNoSpace, "list-item-no-space", Warning, "'*' at start of line is not followed by a space"},
+	}
+}
+
+// LookupRule finds a rule by its ID or its name.
+func LookupRule(idOrName string) (Rule, bool) {
+	for _, rule := range Rules() {
+		if string(rule.ID) == idOrName || rule.Name == idOrName {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Diagnostic is a problem found on a given line.
+type Diagnostic struct {
+	Line     int
+	Rule     RuleID
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d: %s [%s] %s", d.Line, d.Severity, d.Rule, d.Message)
+}
+
+// Config selects which rules are run. The zero value runs every rule.
+// If Enable is set, only those rules are run. Rules in Disable are
+// never run.
+type Config struct {
+	Enable  []RuleID
+	Disable []RuleID
+}
+
+func (cfg Config) enabled(id RuleID) bool {
+	for _, disabled := range cfg.Disable {
+		if disabled == id {
+			return false
+		}
+	}
+
+	if len(cfg.Enable) == 0 {
+		return true
+	}
+
+	for _, enabled := range cfg.Enable {
+		if enabled == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lint will parse r as gemtext and lint it.
+func Lint(r io.Reader, cfg Config) ([]Diagnostic, error) {
+	doc, err := gemtext.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not lint: %w", err)
+	}
+
+	return LintDocument(doc, cfg), nil
+}
+
+// LintDocument will run the enabled rules on doc, returning the diagnostics
+// ordered by line.
+func LintDocument(doc gemtext.Document, cfg Config) []Diagnostic {
+	severities := make(map[RuleID]Severity)
+	for _, rule := range Rules() {
+		severities[rule.ID] = rule.Severity
+	}
+
+	diags := make([]Diagnostic, 0)
+	report := func(line int, id RuleID, format string, args ...any) {
+		if !cfg.enabled(id) {
+			return
+		}
+
+		diags = append(diags, Diagnostic{
+			Line:     line,
+			Rule:     id,
+			Severity: severities[id],
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	openToggle := 0
+
+	for _, line := range doc.Lines {
+		checkLine(line, report)
+
+		if line.Type == gemtext.PreformatToggleLine {
+			if openToggle == 0 {
+				openToggle = line.Number
+			} else {
+				openToggle = 0
+			}
+		}
+	}
+
+	if openToggle != 0 {
+		report(openToggle, UnclosedPreformat, "preformatted block opened here is never closed")
+	}
+
+	return diags
+}
+
+type reportFunc func(line int, id RuleID, format string, args ...any)
+
+func checkLine(line gemtext.Line, report reportFunc) {
+	if line.Type != gemtext.PreformattedTextLine && strings.TrimRight(line.Raw, " \t") != line.Raw {
+		report(line.Number, TrailingWhitespace, "trailing whitespace")
+	}
+
+	switch line.Type {
+	case gemtext.LinkLine:
+		if line.URL == "" {
+			report(line.Number, InvalidLinkURL, "link has no URL")
+
+			return
+		}
+
+		if _, err := url.Parse(line.URL); err != nil {
+			report(line.Number, InvalidLinkURL, "invalid URL '%s'", line.URL)
+		}
+	case gemtext.HeadingLine:
+		rest := line.Raw[line.Level:]
+		if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			report(line.Number, HeadingNoSpace, "expected a space after '%s'", line.Raw[:line.Level])
+		}
+	case gemtext.TextLine:
+		if len(line.Raw) > 1 && line.Raw[0] == '*' && line.Raw[1] != '*' {
+			report(line.Number, ListItemNoSpace, "expected a space after '*' for a list item")
+		}
+	case gemtext.PreformatToggleLine, gemtext.PreformattedTextLine,
+		gemtext.ListItemLine, gemtext.QuoteLine:
+	}
+}
@@ -0,0 +1,65 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	doc := strings.Join([]string{
+		"#Heading",
+		"## Fine heading",
+		"=>",
+		"=> gemini://example.com/%zz bad escape",
+		"text with trailing space ",
+		"*not a list item",
+		"```",
+		"preformatted trailing space is fine ",
+		"```",
+		"```",
+		"never closed",
+	}, "\n")
+
+	diags, err := Lint(strings.NewReader(doc), Config{})
+	if err != nil {
+		t.Fatalf("could not lint: %v", err)
+	}
+
+	want := []struct {
+		line int
+		rule RuleID
+	}{
+		{1, HeadingNoSpace},
+		{3, InvalidLinkURL},
+		{4, InvalidLinkURL},
+		{5, TrailingWhitespace},
+		{6, ListItemNoSpace},
+		{10, UnclosedPreformat},
+	}
+
+	if got, n := len(diags), len(want); got != n {
+		t.Fatalf("got %d diagnostics, want %d: %v", got, n, diags)
+	}
+
+	for k, w := range want {
+		if diags[k].Line != w.line || diags[k].Rule != w.rule {
+			t.Fatalf("(diagnostic %d) got %s, want line %d rule %s", k, diags[k], w.line, w.rule)
+		}
+	}
+
+	t.Run("it honours the config", func(tt *testing.T) {
+		diags, err := Lint(strings.NewReader(doc), Config{
+			Enable:  []RuleID{InvalidLinkURL, TrailingWhitespace},
+			Disable: []RuleID{TrailingWhitespace},
+		})
+		if err != nil {
+			tt.Fatalf("could not lint: %v", err)
+		}
+
+		for _, diag := range diags {
+			if diag.Rule != InvalidLinkURL {
+				tt.Fatalf("unexpected diagnostic: %s", diag)
+			}
+		}
+	})
+}
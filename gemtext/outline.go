@@ -0,0 +1,138 @@
+package gemtext
+
+import "strings"
+
+// Heading is a node in a document's outline. Line is the number of the
+// line the heading was found on, usable as an anchor.
+type Heading struct {
+	Level    int
+	Text     string
+	Line     int
+	Children []*Heading
+}
+
+// Outline builds the heading tree of doc. A heading becomes a child of the
+// closest preceding heading with a lower level, so skipped levels (e.g. a
+// level-3 heading directly under a level-1 heading) are tolerated.
+func Outline(doc Document) []*Heading {
+	roots := make([]*Heading, 0)
+	stack := make([]*Heading, 0, maxHeadingLevel)
+
+	for _, line := range doc.Lines {
+		if line.Type != HeadingLine {
+			continue
+		}
+
+		node := &Heading{Level: line.Level, Text: line.Text, Line: line.Number}
+
+		for len(stack) > 0 && stack[len(stack)-1].Level >= node.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+
+		stack = append(stack, node)
+	}
+
+	return roots
+}
+
+// Title returns the document's title: the text of its first level-1
+// heading or, failing that, of its first heading. It returns an empty
+// string if the document has no headings.
+func Title(doc Document) string {
+	title := ""
+
+	for _, line := range doc.Lines {
+		if line.Type != HeadingLine {
+			continue
+		}
+
+		if line.Level == 1 {
+			return line.Text
+		}
+
+		if title == "" {
+			title = line.Text
+		}
+	}
+
+	return title
+}
+
+// TOC renders headings as a block of gemtext list items, indenting nested
+// headings. If title is set, the block starts with a level-2 heading.
+func TOC(headings []*Heading, title string) []Line {
+	lines := make([]Line, 0)
+
+	if title != "" {
+		lines = append(lines, ParseLine("## "+title, false))
+	}
+
+	var walk func(nodes []*Heading, depth int)
+	walk = func(nodes []*Heading, depth int) {
+		for _, node := range nodes {
+			raw := listItemPrefix + strings.Repeat("  ", depth) + node.Text
+			lines = append(lines, ParseLine(raw, false))
+
+			walk(node.Children, depth+1)
+		}
+	}
+
+	walk(headings, 0)
+
+	return lines
+}
+
+// InsertTOC returns a copy of doc with a table of contents inserted after
+// the title heading, or at the top if the document has none. The title
+// heading itself is not listed. Lines are renumbered.
+func InsertTOC(doc Document, title string) Document {
+	at := 0
+
+	for k, line := range doc.Lines {
+		if line.Type == HeadingLine && line.Level == 1 {
+			at = k + 1
+
+			break
+		}
+	}
+
+	rest := Document{Lines: doc.Lines[at:]}
+	toc := TOC(Outline(rest), title)
+
+	if len(toc) == 0 {
+		return doc
+	}
+
+	blank := ParseLine("", false)
+
+	lines := make([]Line, 0, len(doc.Lines)+len(toc)+2) //nolint:mnd
+	lines = append(lines, doc.Lines[:at]...)
+
+	if at > 0 {
+		lines = append(lines, blank)
+	}
+
+	lines = append(lines, toc...)
+	lines = append(lines, blank)
+
+	// NOTE: avoid doubling the blank line that usually follows the title.
+	restLines := rest.Lines
+	if len(restLines) > 0 && restLines[0].Type == TextLine && restLines[0].Raw == "" {
+		restLines = restLines[1:]
+	}
+
+	lines = append(lines, restLines...)
+
+	for k := range lines {
+		lines[k].Number = k + 1
+	}
+
+	return Document{Lines: lines}
+}
@@ -0,0 +1,43 @@
+package gemtext
+
+import "testing"
+
+const testOutlineDoc = `## Preface
+# Title
+
+## Section 1
+### Section 1.1
+## Section 2
+text
+`
+
+func TestOutline(t *testing.T) {
+	doc := ParseBytes([]byte(testOutlineDoc))
+
+	if got := Title(doc); got != "Title" {
+		t.Fatalf("got title %q, want %q", got, "Title")
+	}
+
+	outline := Outline(doc)
+	if len(outline) != 2 || outline[1].Text != "Title" || outline[1].Line != 2 {
+		t.Fatalf("unexpected roots: %+v", outline)
+	}
+
+	title := outline[1]
+	if len(title.Children) != 2 || title.Children[0].Children[0].Text != "Section 1.1" {
+		t.Fatalf("unexpected children: %+v", title.Children)
+	}
+}
+
+func TestInsertTOC(t *testing.T) {
+	doc := InsertTOC(ParseBytes([]byte("# Title\n\n## One\n### Two\ntext\n")), "Contents")
+
+	want := "# Title\n\n## Contents\n* One\n*   Two\n\n## One\n### Two\ntext\n"
+	if got := doc.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if last := doc.Lines[len(doc.Lines)-1]; last.Number != len(doc.Lines) {
+		t.Fatalf("lines were not renumbered: %+v", last)
+	}
+}
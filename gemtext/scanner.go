@@ -0,0 +1,137 @@
+package gemtext
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// DefaultMaxLineSize is the maximum line length kept by a Scanner
+	// created with NewScanner.
+	DefaultMaxLineSize = 16 * 1024
+	scannerBufferSize  = 4 * 1024
+)
+
+// Scanner is an incremental gemtext tokenizer. It reads one line at a
+// time from the underlying reader, tracking the preformatted toggle state,
+// so documents can be rendered as they arrive.
+//
+// Lines longer than MaxLineSize bytes are truncated, with the rest of the
+// line discarded and Line.Truncated set, keeping memory usage constant.
+type Scanner struct {
+	// MaxLineSize is the maximum number of bytes kept per line. Zero or
+	// negative means no limit. It must be set before the first call to Scan.
+	MaxLineSize int
+
+	r            *bufio.Reader
+	buf          []byte
+	line         Line
+	err          error
+	number       int
+	dropped      int
+	lastDropped  byte
+	preformatted bool
+	done         bool
+}
+
+// NewScanner returns a Scanner reading from r, with MaxLineSize set
+// to DefaultMaxLineSize.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{
+		MaxLineSize: DefaultMaxLineSize,
+		r:           bufio.NewReaderSize(r, scannerBufferSize),
+	}
+}
+
+// Scan advances to the next line, which is then available through Line.
+// It returns false when the input is exhausted or an error occurred.
+func (s *Scanner) Scan() bool {
+	if s.done {
+		return false
+	}
+
+	s.buf = s.buf[:0]
+	s.dropped = 0
+	read := false
+
+	for {
+		chunk, err := s.r.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		s.appendChunk(trimByte(chunk, '\n'))
+
+		if err == nil {
+			break
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		s.done = true
+
+		if !errors.Is(err, io.EOF) {
+			s.err = fmt.Errorf("error reading line: %w", err)
+		}
+
+		if !read {
+			return false
+		}
+
+		break
+	}
+
+	s.number++
+
+	s.line = ParseLine(string(trimByte(s.buf, '\r')), s.preformatted)
+	s.line.Number = s.number
+	// NOTE: a dropped CR is part of the terminator, not the line.
+	s.line.Truncated = s.dropped > 1 || (s.dropped == 1 && s.lastDropped != '\r')
+
+	if s.line.Type == PreformatToggleLine {
+		s.preformatted = !s.preformatted
+	}
+
+	return true
+}
+
+// appendChunk appends as much of chunk as MaxLineSize allows, keeping
+// track of what was dropped.
+func (s *Scanner) appendChunk(chunk []byte) {
+	room := len(chunk)
+	if s.MaxLineSize > 0 {
+		room = min(room, max(s.MaxLineSize-len(s.buf), 0))
+	}
+
+	s.buf = append(s.buf, chunk[:room]...)
+
+	if dropped := chunk[room:]; len(dropped) > 0 {
+		s.dropped += len(dropped)
+		s.lastDropped = dropped[len(dropped)-1]
+	}
+}
+
+func trimByte(p []byte, b byte) []byte {
+	if n := len(p); n > 0 && p[n-1] == b {
+		return p[:n-1]
+	}
+
+	return p
+}
+
+// Line returns the line read by the last call to Scan.
+func (s *Scanner) Line() Line {
+	return s.line
+}
+
+// Preformatted reports whether the scanner is currently inside a
+// preformatted block.
+func (s *Scanner) Preformatted() bool {
+	return s.preformatted
+}
+
+// Err returns the first non-EOF error encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
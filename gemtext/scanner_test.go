@@ -0,0 +1,63 @@
+package gemtext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	long := strings.Repeat("x", 64)
+	input := "# Title\r\n```\n=> /not-a-link\n```\n" + long + "\r\n=> /link\r\nno terminator"
+
+	scanner := NewScanner(strings.NewReader(input))
+	scanner.MaxLineSize = 32
+
+	want := []struct {
+		typ       LineType
+		raw       string
+		truncated bool
+	}{
+		{HeadingLine, "# Title", false},
+		{PreformatToggleLine, "```", false},
+		{PreformattedTextLine, "=> /not-a-link", false},
+		{PreformatToggleLine, "```", false},
+		{TextLine, long[:32], true},
+		{LinkLine, "=> /link", false},
+		{TextLine, "no terminator", false},
+	}
+
+	k := 0
+	for ; scanner.Scan(); k++ {
+		if k >= len(want) {
+			t.Fatalf("unexpected line: %+v", scanner.Line())
+		}
+
+		line := scanner.Line()
+		w := want[k]
+
+		if line.Type != w.typ || line.Raw != w.raw || line.Truncated != w.truncated || line.Number != k+1 {
+			t.Fatalf("(line %d) got %+v, want %+v", k+1, line, w)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if k != len(want) {
+		t.Fatalf("got %d lines, want %d", k, len(want))
+	}
+
+	t.Run("a CR that does not fit is not a truncation", func(tt *testing.T) {
+		scanner := NewScanner(strings.NewReader(long[:32] + "\r\n"))
+		scanner.MaxLineSize = 32
+
+		if !scanner.Scan() {
+			tt.Fatalf("expected a line")
+		}
+
+		if line := scanner.Line(); line.Truncated || line.Raw != long[:32] {
+			tt.Fatalf("unexpected line: %+v", line)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package gemtext
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// zeroWidthSpace is prepended to escaped lines: it is invisible when
+// rendered, but stops the line from starting with a line type's prefix.
+const zeroWidthSpace = "\u200b"
+
+const escapeFuncName = "_gemtext_escape"
+
+// lineTypePrefixes holds the first character of every line type prefix.
+const lineTypePrefixes = "=#*>`"
+
+// Escape makes s safe to interpolate in a gemtext document: every line of s
+// starting with the first character of a line type's prefix (a link,
+// heading, list item, quote or preformat toggle) is prefixed with a zero
+// width space. Such lines are escaped even if they parse as text on their
+// own, as the template's literal text could complete the prefix.
+func Escape(s string) string {
+	if !strings.ContainsAny(s, lineTypePrefixes) {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for k, line := range lines {
+		if line != "" && strings.ContainsRune(lineTypePrefixes, rune(line[0])) {
+			lines[k] = zeroWidthSpace + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Raw marks a value as trusted gemtext, which Template does not escape.
+type Raw string
+
+// Template is a text/template whose actions are escaped with Escape, so
+// values cannot start new line types. Use the raw function, or values of
+// type Raw, to output gemtext as is:
+//
+//	=> {{.URL}} {{.Label}}
+//	{{raw .Markup}}
+type Template struct {
+	text    *template.Template
+	escaped map[*parse.Tree]bool
+}
+
+// New allocates a new template with the given name.
+func New(name string) *Template {
+	t := &Template{
+		text:    template.New(name),
+		escaped: make(map[*parse.Tree]bool),
+	}
+
+	t.text.Funcs(template.FuncMap{
+		escapeFuncName: escapeValue,
+		"raw":          func(s string) Raw { return Raw(s) },
+	})
+
+	return t
+}
+
+// Must panics if err is non-nil, like template.Must.
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// Name returns the name of the template.
+func (t *Template) Name() string {
+	return t.text.Name()
+}
+
+// Funcs adds fm to the template's function map. It must be called before
+// Parse.
+func (t *Template) Funcs(fm template.FuncMap) *Template {
+	t.text.Funcs(fm)
+
+	return t
+}
+
+// Parse parses text as the template's body, escaping its actions.
+func (t *Template) Parse(text string) (*Template, error) {
+	if _, err := t.text.Parse(text); err != nil {
+		return nil, fmt.Errorf("could not parse template: %w", err)
+	}
+
+	for _, tmpl := range t.text.Templates() {
+		if tmpl.Tree == nil || t.escaped[tmpl.Tree] {
+			continue
+		}
+
+		escapeList(tmpl.Tree.Root)
+		t.escaped[tmpl.Tree] = true
+	}
+
+	return t, nil
+}
+
+// Execute applies the template to data, writing the output to w.
+func (t *Template) Execute(w io.Writer, data any) error {
+	return t.text.Execute(w, data) //nolint:wrapcheck
+}
+
+// ExecuteTemplate applies the template associated with t that has the
+// given name to data, writing the output to w.
+func (t *Template) ExecuteTemplate(w io.Writer, name string, data any) error {
+	return t.text.ExecuteTemplate(w, name, data) //nolint:wrapcheck
+}
+
+// escapeList appends the escaping function to the pipeline of every action
+// printing a value in list.
+func escapeList(list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) > 0 {
+				continue
+			}
+
+			ident := parse.NewIdentifier(escapeFuncName).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{ident},
+			})
+		case *parse.IfNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		case *parse.RangeNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		case *parse.WithNode:
+			escapeList(n.List)
+			escapeList(n.ElseList)
+		}
+	}
+}
+
+func escapeValue(args ...any) string {
+	if len(args) == 1 {
+		if raw, ok := args[0].(Raw); ok {
+			return string(raw)
+		}
+	}
+
+	return Escape(fmt.Sprint(args...))
+}
@@ -0,0 +1,98 @@
+package gemtext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"=> gemini://evil.example", zeroWidthSpace + "=> gemini://evil.example"},
+		{"# heading", zeroWidthSpace + "# heading"},
+		{"* item", zeroWidthSpace + "* item"},
+		{"*emphasis*", zeroWidthSpace + "*emphasis*"},
+		{"*", zeroWidthSpace + "*"},
+		{"=", zeroWidthSpace + "="},
+		{"`", zeroWidthSpace + "`"},
+		{"> quote", zeroWidthSpace + "> quote"},
+		{"```", zeroWidthSpace + "```"},
+		{"a = b > c # d", "a = b > c # d"},
+		{"first\n=> /x\r\nlast", "first\n" + zeroWidthSpace + "=> /x\r\nlast"},
+	}
+
+	for _, c := range cases {
+		if got := Escape(c.in); got != c.want {
+			t.Errorf("Escape(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl := Must(New("page").Funcs(map[string]any{"upper": strings.ToUpper}).Parse(
+		"# {{.Title}}\n" +
+			"{{range .Comments}}{{.}}\n{{end}}" +
+			"{{with .Link}}=> {{.}} link{{end}}\n" +
+			"{{$x := .Title}}{{upper $x}}\n" +
+			"{{raw .Markup}}\n" +
+			`{{template "footer" .}}` +
+			`{{define "footer"}}{{.Footer}}{{end}}`,
+	))
+
+	data := map[string]any{
+		"Title":    "=> not a link",
+		"Comments": []string{"# not a heading", "fine", "* not\n> a quote"},
+		"Link":     "/ok",
+		"Markup":   "=> /trusted trusted",
+		"Footer":   Raw("## trusted footer"),
+	}
+
+	bdr := &strings.Builder{}
+	if err := tmpl.Execute(bdr, data); err != nil {
+		t.Fatalf("could not execute: %v", err)
+	}
+
+	doc := ParseBytes([]byte(bdr.String()))
+
+	counts := make(map[LineType]int)
+	for _, line := range doc.Lines {
+		counts[line.Type]++
+	}
+
+	want := map[LineType]int{HeadingLine: 2, LinkLine: 2, TextLine: 5}
+	for lt, n := range want {
+		if counts[lt] != n {
+			t.Errorf("got %d %s lines, want %d:\n%s", counts[lt], lt, n, bdr.String())
+		}
+	}
+
+	if !strings.Contains(bdr.String(), zeroWidthSpace+"=> NOT A LINK") {
+		t.Errorf("function results must be escaped too:\n%s", bdr.String())
+	}
+}
+
+func TestTemplatePrefixInjection(t *testing.T) {
+	tmpl := Must(New("page").Parse(
+		"{{.Name}} said hi\n" +
+			"{{.Eq}}> /evil click\n" +
+			"{{.Hash}} not a heading\n" +
+			"{{.Quote}} not a quote\n" +
+			"{{.Tick}}``\n",
+	))
+
+	data := map[string]string{"Name": "*", "Eq": "=", "Hash": "#", "Quote": ">", "Tick": "`"}
+
+	bdr := &strings.Builder{}
+	if err := tmpl.Execute(bdr, data); err != nil {
+		t.Fatalf("could not execute: %v", err)
+	}
+
+	for _, line := range ParseBytes([]byte(bdr.String())).Lines {
+		if line.Type != TextLine {
+			t.Errorf("value combined with literal text into a %s line:\n%s", line.Type, bdr.String())
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package libgemini
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// InputHandlerFunc handles a request once the client provided input.
+type InputHandlerFunc func(w ResponseWriter, r *Request, input string)
+
+// PromptInput returns a handler asking for input with prompt: requests
+// without a query are answered with Input, or SensitiveInput if sensitive
+// is set, and the client then repeats the request with the user's input as
+// query. fn is called with the unescaped input. An empty query ("?") is
+// passed on as empty input.
+func PromptInput(prompt string, sensitive bool, fn InputHandlerFunc) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		input, ok, err := requestInput(r)
+		if err != nil {
+			Error(w, BadRequest, "invalid input")
+
+			return
+		}
+
+		if !ok {
+			askInput(w, prompt, sensitive)
+
+			return
+		}
+
+		fn(w, r, input)
+	})
+}
+
+func requestInput(r *Request) (string, bool, error) {
+	if r.u.RawQuery == "" && !r.u.ForceQuery {
+		return "", false, nil
+	}
+
+	input, err := url.PathUnescape(r.u.RawQuery)
+	if err != nil {
+		return "", false, err //nolint:wrapcheck
+	}
+
+	return input, true, nil
+}
+
+func askInput(w ResponseWriter, prompt string, sensitive bool) {
+	status := Input
+	if sensitive {
+		status = SensitiveInput
+	}
+
+	w.WriteHeader(status, prompt)
+}
+
+// WizardStep is a single question of a Wizard.
+type WizardStep struct {
+	Prompt    string
+	Sensitive bool
+
+	// Validate, if set, checks the answer. If it returns an error, the
+	// question is asked again, using the error's message as prompt.
+	Validate func(answer string) error
+}
+
+func (s WizardStep) validate(answer string) error {
+	if s.Validate == nil {
+		return nil
+	}
+
+	return s.Validate(answer)
+}
+
+// Wizard is a handler asking a series of questions, then calling Done with
+// the answers.
+//
+// The answers given so far are kept in the path: each one is appended as
+// an encoded segment, and the client is redirected to the next step with a
+// relative redirect. Mount a Wizard below a path ending in a slash, using
+// StripPrefix:
+//
+//	mux.Handle("/signup/", StripPrefix("/signup", &Wizard{...}))
+//
+// The answer to the last step is passed to Done without a redirect, so it
+// never appears in a path. Answers to earlier sensitive steps do, and may
+// end up in logs: prefer asking for sensitive input last.
+//
+// Since the path is chosen by the client, answers read back from it are
+// validated again before being used.
+type Wizard struct {
+	Steps []WizardStep
+	Done  func(w ResponseWriter, r *Request, answers []string)
+}
+
+// wizardSegmentPrefix keeps encoded answers from being empty segments.
+const wizardSegmentPrefix = "_"
+
+func (wz *Wizard) ServeGemini(w ResponseWriter, r *Request) {
+	answers, ok := decodeWizardPath(r.u.Path)
+	if !ok || len(answers) >= len(wz.Steps) {
+		Error(w, NotFound, "not found")
+
+		return
+	}
+
+	for i, answer := range answers {
+		if err := wz.Steps[i].validate(answer); err != nil {
+			Error(w, BadRequest, "invalid answer")
+
+			return
+		}
+	}
+
+	step := wz.Steps[len(answers)]
+
+	input, hasInput, err := requestInput(r)
+	if err != nil {
+		Error(w, BadRequest, "invalid input")
+
+		return
+	}
+
+	if !hasInput {
+		askInput(w, step.Prompt, step.Sensitive)
+
+		return
+	}
+
+	if err := step.validate(input); err != nil {
+		askInput(w, err.Error(), step.Sensitive)
+
+		return
+	}
+
+	answers = append(answers, input)
+
+	if len(answers) == len(wz.Steps) {
+		wz.Done(w, r, answers)
+
+		return
+	}
+
+	next := "./"
+	if !strings.HasSuffix(r.u.Path, "/") {
+		next = "./" + lastSegment(r.u.Path) + "/"
+	}
+
+	Redirect(w, next+encodeWizardAnswer(input)+"/", RedirectTemporary)
+}
+
+func encodeWizardAnswer(answer string) string {
+	return wizardSegmentPrefix + base64.RawURLEncoding.EncodeToString([]byte(answer))
+}
+
+// decodeWizardPath returns the answers encoded in upath.
+func decodeWizardPath(upath string) ([]string, bool) {
+	answers := make([]string, 0)
+
+	for _, seg := range strings.Split(strings.Trim(upath, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+
+		encoded, found := strings.CutPrefix(seg, wizardSegmentPrefix)
+		if !found {
+			return nil, false
+		}
+
+		answer, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, false
+		}
+
+		answers = append(answers, string(answer))
+	}
+
+	return answers, true
+}
+
+func lastSegment(upath string) string {
+	return upath[strings.LastIndex(upath, "/")+1:]
+}
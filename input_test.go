@@ -0,0 +1,166 @@
+package libgemini
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPromptInput(t *testing.T) {
+	h := PromptInput("name?", false, func(w ResponseWriter, _ *Request, input string) {
+		w.Write([]byte("hello " + input))
+	})
+
+	cases := []struct {
+		url    string
+		status StatusCode
+		meta   string
+		body   string
+	}{
+		{"gemini://example.com/greet", Input, "name?", ""},
+		{"gemini://example.com/greet?Jane%20Doe", Success, DefaultMIME, "hello Jane Doe"},
+		{"gemini://example.com/greet?", Success, DefaultMIME, "hello "},
+		{"gemini://example.com/greet?" + url.PathEscape("C++ & Go"), Success, DefaultMIME, "hello C++ & Go"},
+		{"gemini://example.com/greet?C++", Success, DefaultMIME, "hello C++"},
+		{"gemini://example.com/greet?%zz", BadRequest, "invalid input", ""},
+	}
+
+	for _, c := range cases {
+		rec := &recorder{}
+		h.ServeGemini(rec, mustRequest(t, c.url))
+
+		if rec.status != c.status || rec.meta != c.meta || rec.body.String() != c.body {
+			t.Errorf("%s: got '%d %s' %q", c.url, rec.status, rec.meta, rec.body.String())
+		}
+	}
+
+	rec := &recorder{}
+	PromptInput("password?", true, nil).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != SensitiveInput || rec.meta != "password?" {
+		t.Errorf("got '%d %s'", rec.status, rec.meta)
+	}
+}
+
+func TestWizard(t *testing.T) {
+	var got []string
+
+	wizard := &Wizard{
+		Steps: []WizardStep{
+			{Prompt: "name?"},
+			{Prompt: "age?", Validate: func(answer string) error {
+				if strings.Trim(answer, "0123456789") != "" {
+					return errors.New("age must be a number")
+				}
+
+				return nil
+			}},
+			{Prompt: "password?", Sensitive: true},
+		},
+		Done: func(w ResponseWriter, _ *Request, answers []string) {
+			got = answers
+			w.Write([]byte("done"))
+		},
+	}
+
+	mux := NewServeMux()
+	mux.Handle("/signup/", StripPrefix("/signup", wizard))
+
+	// NOTE: answers are typed by the user at each prompt, in order.
+	inputs := []string{"a/b c?", "old", "42", "s3cret"}
+	current := "gemini://example.com/signup/"
+
+	var steps []string
+
+	for range 10 {
+		rec := &recorder{}
+		mux.ServeGemini(rec, mustRequest(t, current))
+
+		steps = append(steps, rec.meta)
+
+		switch {
+		case rec.status == Input || rec.status == SensitiveInput:
+			base, _ := url.Parse(current)
+			base.RawQuery = url.PathEscape(inputs[0])
+			inputs = inputs[1:]
+			current = base.String()
+		case rec.status == RedirectTemporary:
+			base, _ := url.Parse(current)
+			ref, _ := url.Parse(rec.meta)
+			current = base.ResolveReference(ref).String()
+		default:
+			if rec.status != Success || rec.body.String() != "done" {
+				t.Fatalf("unexpected response '%d %s'", rec.status, rec.meta)
+			}
+
+			want := []string{"a/b c?", "42", "s3cret"}
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("got answers %q, want %q", got, want)
+			}
+
+			if strings.Contains(strings.Join(steps, " "), "czNjcmV0") {
+				t.Errorf("the last answer must not be encoded in a path: %v", steps)
+			}
+
+			wantSteps := "name? ./_YS9iIGM_/ age? age must be a number ./_NDI/ password?"
+			if s := strings.Join(steps[:len(steps)-1], " "); s != wantSteps {
+				t.Errorf("got steps '%s', want '%s'", s, wantSteps)
+			}
+
+			return
+		}
+	}
+
+	t.Fatalf("wizard did not finish: %v", steps)
+}
+
+func TestWizardInvalidPath(t *testing.T) {
+	wizard := &Wizard{Steps: []WizardStep{{Prompt: "name?"}}}
+
+	for _, path := range []string{"/not-an-answer/", "/_YQ/"} {
+		rec := &recorder{}
+		wizard.ServeGemini(rec, mustRequest(t, "gemini://example.com"+path))
+
+		if rec.status != NotFound {
+			t.Errorf("%s: got '%d %s'", path, rec.status, rec.meta)
+		}
+	}
+}
+
+func TestWizardForgedPath(t *testing.T) {
+	var done bool
+
+	wizard := &Wizard{
+		Steps: []WizardStep{
+			{Prompt: "answer?", Validate: func(answer string) error {
+				if answer != "42" {
+					return errors.New("wrong answer")
+				}
+
+				return nil
+			}},
+			{Prompt: "name?"},
+		},
+		Done: func(w ResponseWriter, _ *Request, _ []string) {
+			done = true
+			w.Write([]byte("done"))
+		},
+	}
+
+	// NOTE: "_ZXZpbA" encodes "evil", which step 1 never accepted; "_NDI"
+	// encodes "42".
+	rec := &recorder{}
+	wizard.ServeGemini(rec, mustRequest(t, "gemini://example.com/_ZXZpbA/?bob"))
+
+	if done || rec.status != BadRequest {
+		t.Errorf("forged answer accepted: '%d %s'", rec.status, rec.meta)
+	}
+
+	rec = &recorder{}
+	wizard.ServeGemini(rec, mustRequest(t, "gemini://example.com/_NDI/?bob"))
+
+	if !done || rec.status != Success {
+		t.Errorf("valid answer rejected: '%d %s'", rec.status, rec.meta)
+	}
+}
@@ -0,0 +1,158 @@
+package libgemini
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aalbacetef/libgemini/gemtext"
+)
+
+// LinkKind classifies a link by the scheme it was written with.
+type LinkKind int
+
+const (
+	LinkOther LinkKind = iota
+	LinkGemini
+	LinkTitan
+	LinkHTTP
+	LinkGopher
+	LinkMailto
+	LinkRelative
+)
+
+func (kind LinkKind) String() string {
+	switch kind {
+	case LinkGemini:
+		return "gemini"
+	case LinkTitan:
+		return "titan"
+	case LinkHTTP:
+		return "http"
+	case LinkGopher:
+		return "gopher"
+	case LinkMailto:
+		return "mailto"
+	case LinkRelative:
+		return "relative"
+	case LinkOther:
+		return "other"
+	default:
+		return "unknown"
+	}
+}
+
+const titanScheme = "titan"
+
+// Link is a link line found in a gemtext document.
+//
+// URL is always resolved against the base URL, while Kind reflects
+// how the link was written: links without a scheme are LinkRelative.
+type Link struct {
+	URL   *url.URL
+	Label string
+	Kind  LinkKind
+	Line  int
+}
+
+// Links will extract the links found in the response's content, resolving
+// them against the URL of the request that produced the response.
+// It returns nil if the response is not a successful text/gemini response.
+func (resp Response) Links() []Link {
+	if !resp.Header.Status.IsSuccess() {
+		return nil
+	}
+
+	if !isGemtext(resp.Header.Meta) {
+		return nil
+	}
+
+	return ExtractLinks(resp.req.URL(), resp.Content)
+}
+
+func isGemtext(meta string) bool {
+	mediaType, _, _ := strings.Cut(meta, ";")
+
+	return strings.EqualFold(strings.TrimSpace(mediaType), gemtext.MIME)
+}
+
+// ExtractLinks will parse content as gemtext and return its links, resolved
+// against base (which may be nil). Fragments are removed, default ports are
+// dropped and links pointing to the same resource are only returned once,
+// keeping the first occurrence. Link lines with invalid URLs are skipped.
+func ExtractLinks(base *url.URL, content []byte) []Link {
+	doc := gemtext.ParseBytes(content)
+	seen := make(map[string]struct{})
+	links := make([]Link, 0)
+
+	for _, line := range doc.Lines {
+		if line.Type != gemtext.LinkLine || line.URL == "" {
+			continue
+		}
+
+		ref, err := url.Parse(line.URL)
+		if err != nil {
+			continue
+		}
+
+		kind := linkKind(ref)
+
+		if base != nil {
+			ref = base.ResolveReference(ref)
+		}
+
+		normalizeLink(ref)
+
+		key := ref.String()
+		if _, found := seen[key]; found {
+			continue
+		}
+
+		seen[key] = struct{}{}
+
+		links = append(links, Link{
+			URL:   ref,
+			Label: line.Text,
+			Kind:  kind,
+			Line:  line.Number,
+		})
+	}
+
+	return links
+}
+
+func linkKind(u *url.URL) LinkKind {
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		return LinkRelative
+	case geminiScheme:
+		return LinkGemini
+	case titanScheme:
+		return LinkTitan
+	case "http", "https":
+		return LinkHTTP
+	case "gopher":
+		return LinkGopher
+	case "mailto":
+		return LinkMailto
+	default:
+		return LinkOther
+	}
+}
+
+// normalizeLink strips the fragment, lowercases the host and drops the
+// port when it is the default one for the scheme.
+func normalizeLink(u *url.URL) {
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Host = strings.ToLower(u.Host)
+
+	switch u.Scheme {
+	case geminiScheme, titanScheme:
+		u.Host = strings.TrimSuffix(u.Host, ":"+strconv.Itoa(geminiPort))
+
+		if u.Host != "" && u.Path == "" {
+			u.Path = "/"
+		}
+	}
+}
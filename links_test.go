@@ -0,0 +1,75 @@
+package libgemini
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestExtractLinks(t *testing.T) {
+	base, err := url.Parse("gemini://example.com:1965/dir/page.gmi")
+	if err != nil {
+		t.Fatalf("could not parse base: %v", err)
+	}
+
+	content := []byte(`# Links
+=> other.gmi Other
+=> gemini://Example.com/dir/other.gmi#section Duplicate
+=> gemini://example.org
+=> titan://example.com/upload Upload
+=> https://example.com Web
+=> gopher://example.com Gopher
+=> mailto:someone@example.com Mail
+` + "```\n=> /preformatted\n```\n")
+
+	want := []struct {
+		url   string
+		label string
+		kind  LinkKind
+	}{
+		{"gemini://example.com/dir/other.gmi", "Other", LinkRelative},
+		{"gemini://example.org/", "", LinkGemini},
+		{"titan://example.com/upload", "Upload", LinkTitan},
+		{"https://example.com", "Web", LinkHTTP},
+		{"gopher://example.com", "Gopher", LinkGopher},
+		{"mailto:someone@example.com", "Mail", LinkMailto},
+	}
+
+	links := ExtractLinks(base, content)
+	if got, n := len(links), len(want); got != n {
+		t.Fatalf("got %d links, want %d: %v", got, n, links)
+	}
+
+	for k, w := range want {
+		link := links[k]
+
+		if link.URL.String() != w.url || link.Label != w.label || link.Kind != w.kind {
+			t.Fatalf(
+				"(link %d) got (%s, %q, %s), want (%s, %q, %s)",
+				k, link.URL, link.Label, link.Kind, w.url, w.label, w.kind,
+			)
+		}
+	}
+}
+
+func TestResponseLinks(t *testing.T) {
+	req, err := NewRequest("example.com/")
+	if err != nil {
+		t.Fatalf("could not create request: %v", err)
+	}
+
+	resp := Response{
+		Header:  Header{Status: Success, Meta: "text/gemini; lang=en"},
+		Content: []byte("=> /about About\n"),
+		req:     req,
+	}
+
+	links := resp.Links()
+	if len(links) != 1 || links[0].URL.String() != "gemini://example.com/about" {
+		t.Fatalf("unexpected links: %v", links)
+	}
+
+	resp.Header.Meta = "text/plain"
+	if links := resp.Links(); links != nil {
+		t.Fatalf("expected no links for text/plain, got %v", links)
+	}
+}
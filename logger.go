@@ -16,10 +16,7 @@ type FileHandler struct {
 }
 
 func NewFileHandler(ctx context.Context, wc io.WriteCloser) FileHandler {
-	go func() {
-		<-ctx.Done()
-		wc.Close()
-	}()
+	closeOnDone(ctx, wc)
 
 	return FileHandler{
 		wc:      wc,
@@ -27,6 +24,13 @@ func NewFileHandler(ctx context.Context, wc io.WriteCloser) FileHandler {
 	}
 }
 
+func closeOnDone(ctx context.Context, wc io.WriteCloser) {
+	go func() {
+		<-ctx.Done()
+		wc.Close()
+	}()
+}
+
 type NoopHandler struct{}
 
 func (NoopHandler) Enabled(context.Context, slog.Level) bool {
@@ -45,6 +49,16 @@ func (NoopHandler) WithGroup(string) slog.Handler {
 	return NoopHandler{}
 }
 
+// loggerOrNoop returns logger, or a logger discarding everything if it is
+// nil.
+func loggerOrNoop(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(NoopHandler{})
+	}
+
+	return logger
+}
+
 type NoopCloser struct {
 	io.Writer
 }
@@ -54,10 +68,11 @@ func (NoopCloser) Close() error {
 }
 
 const (
-	fileFlags = os.O_CREATE | os.O_TRUNC | os.O_WRONLY
-	filePerms = fs.FileMode(0o644)
-	StdOutKey = ":stdout:"
-	StdErrKey = ":stderr:"
+	fileFlags       = os.O_CREATE | os.O_TRUNC | os.O_WRONLY
+	appendFileFlags = os.O_CREATE | os.O_APPEND | os.O_WRONLY
+	filePerms       = fs.FileMode(0o644)
+	StdOutKey       = ":stdout:"
+	StdErrKey       = ":stderr:"
 )
 
 // NewLoggerFromPath will take in a path and if it's empty it will
@@ -70,12 +85,22 @@ func NewLoggerFromPath(ctx context.Context, fpath string) (*slog.Logger, error)
 		return slog.New(NoopHandler{}), nil
 	}
 
-	if fpath == StdOutKey {
-		return slog.New(NewFileHandler(ctx, NoopCloser{os.Stdout})), nil
+	wc, err := openLogOutput(fpath, fileFlags)
+	if err != nil {
+		return nil, err
 	}
 
-	if fpath == StdErrKey {
-		return slog.New(NewFileHandler(ctx, NoopCloser{os.Stderr})), nil
+	return slog.New(NewFileHandler(ctx, wc)), nil
+}
+
+// openLogOutput opens fpath, or returns StdOut or StdErr for the
+// :stdout: and :stderr: keys.
+func openLogOutput(fpath string, flags int) (io.WriteCloser, error) {
+	switch fpath {
+	case StdOutKey:
+		return NoopCloser{os.Stdout}, nil
+	case StdErrKey:
+		return NoopCloser{os.Stderr}, nil
 	}
 
 	abspath, err := filepath.Abs(fpath)
@@ -83,10 +108,10 @@ func NewLoggerFromPath(ctx context.Context, fpath string) (*slog.Logger, error)
 		return nil, fmt.Errorf("invalid path '%s': %w", fpath, err)
 	}
 
-	fd, err := os.OpenFile(abspath, fileFlags, filePerms)
+	fd, err := os.OpenFile(abspath, flags, filePerms)
 	if err != nil {
 		return nil, fmt.Errorf("could not open file '%s': %w", abspath, err)
 	}
 
-	return slog.New(NewFileHandler(ctx, fd)), nil
+	return fd, nil
 }
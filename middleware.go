@@ -0,0 +1,239 @@
+package libgemini
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	ErrHandlerTimeout = errors.New("handler timed out")
+	ErrMissingHeader  = errors.New("body written before the header")
+)
+
+// Middleware wraps a Handler, adding behavior before or after it.
+type Middleware func(Handler) Handler
+
+// Chain composes middlewares into one. The first middleware is the
+// outermost: Chain(a, b, c)(h) is a(b(c(h))).
+func Chain(middlewares ...Middleware) Middleware {
+	return func(h Handler) Handler {
+		for k := len(middlewares) - 1; k >= 0; k-- {
+			h = middlewares[k](h)
+		}
+
+		return h
+	}
+}
+
+// Recover returns middleware recovering from panics in handlers. The panic
+// and its stack trace are logged to logger and, if no header was written
+// yet, the request is answered with TemporaryFailure. Nothing is logged if
+// logger is nil.
+func Recover(logger *slog.Logger) Middleware {
+	logger = loggerOrNoop(logger)
+
+	return func(h Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			sw := &statusWriter{ResponseWriter: w}
+
+			defer func() {
+				val := recover()
+				if val == nil {
+					return
+				}
+
+				logger.Error(
+					"handler panicked",
+					"url", r.u.String(),
+					"panic", fmt.Sprint(val),
+					"stack", string(debug.Stack()),
+				)
+
+				if !sw.wroteHeader {
+					Error(sw, TemporaryFailure, "internal server error")
+				}
+			}()
+
+			h.ServeGemini(sw, r)
+		})
+	}
+}
+
+// Timeout returns middleware answering requests whose handler runs for
+// longer than d with TemporaryFailure. The request's context is canceled
+// when the timeout is reached, and later writes by the handler fail with
+// ErrHandlerTimeout.
+//
+// The response is buffered until the handler returns, so Timeout is not
+// suitable for streaming handlers.
+func Timeout(d time.Duration) Middleware {
+	return func(h Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{ctx: ctx}
+			done := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					done <- recover()
+				}()
+
+				h.ServeGemini(tw, r.WithContext(ctx))
+			}()
+
+			select {
+			case val := <-done:
+				if val != nil {
+					// NOTE: re-panic in the caller's goroutine, so Recover sees it.
+					panic(val)
+				}
+
+				tw.flush(w)
+			case <-ctx.Done():
+				Error(w, TemporaryFailure, "timeout")
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a response until the handler returns. Once ctx is
+// done, writes are dropped: checking ctx itself, rather than waiting to be
+// told, keeps a handler woken by the timeout from writing first.
+type timeoutWriter struct {
+	ctx         context.Context //nolint:containedctx
+	mu          sync.Mutex
+	status      StatusCode
+	meta        string
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (tw *timeoutWriter) WriteHeader(status StatusCode, meta string) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.ctx.Err() != nil || tw.wroteHeader {
+		return
+	}
+
+	tw.status, tw.meta, tw.wroteHeader = status, meta, true
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.ctx.Err() != nil {
+		return 0, ErrHandlerTimeout
+	}
+
+	if !tw.wroteHeader {
+		tw.status, tw.meta, tw.wroteHeader = Success, DefaultMIME, true
+	}
+
+	if !tw.status.IsSuccess() {
+		return 0, ErrBodyNotAllowed
+	}
+
+	return tw.body.Write(p) //nolint:wrapcheck
+}
+
+func (tw *timeoutWriter) flush(w ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.wroteHeader {
+		return
+	}
+
+	w.WriteHeader(tw.status, tw.meta)
+
+	if tw.body.Len() > 0 {
+		_, _ = w.Write(tw.body.Bytes()) //nolint:errcheck
+	}
+}
+
+type requestIDKey struct{}
+
+const requestIDBytes = 8
+
+// RequestID is middleware giving every request a random identifier,
+// available to handlers through RequestIDFromContext.
+func RequestID(h Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		buf := make([]byte, requestIDBytes)
+		_, _ = rand.Read(buf) //nolint:errcheck
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, hex.EncodeToString(buf))
+
+		h.ServeGemini(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the identifier set by RequestID, or an
+// empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// StrictHeaders returns middleware enforcing that handlers write exactly
+// one header, before any body. Violations are logged to logger: extra
+// headers are dropped, a body written before the header fails with
+// ErrMissingHeader, and if no header was written the request is answered
+// with TemporaryFailure. Nothing is logged if logger is nil.
+func StrictHeaders(logger *slog.Logger) Middleware {
+	logger = loggerOrNoop(logger)
+
+	return func(h Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			sw := &strictWriter{ResponseWriter: w, logger: logger, url: r.u.String()}
+
+			h.ServeGemini(sw, r)
+
+			if !sw.wroteHeader {
+				logger.Error("handler wrote no header", "url", sw.url)
+				Error(w, TemporaryFailure, "internal server error")
+			}
+		})
+	}
+}
+
+type strictWriter struct {
+	ResponseWriter
+	logger      *slog.Logger
+	url         string
+	wroteHeader bool
+}
+
+func (sw *strictWriter) WriteHeader(status StatusCode, meta string) {
+	if sw.wroteHeader {
+		sw.logger.Error("handler wrote more than one header", "url", sw.url, "status", status, "meta", meta)
+
+		return
+	}
+
+	sw.wroteHeader = true
+	sw.ResponseWriter.WriteHeader(status, meta)
+}
+
+func (sw *strictWriter) Write(p []byte) (int, error) {
+	if !sw.wroteHeader {
+		sw.logger.Error("handler wrote a body before the header", "url", sw.url)
+
+		return 0, ErrMissingHeader
+	}
+
+	return sw.ResponseWriter.Write(p) //nolint:wrapcheck
+}
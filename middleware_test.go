@@ -0,0 +1,189 @@
+package libgemini
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	order := make([]string, 0)
+
+	tag := func(name string) Middleware {
+		return func(h Handler) Handler {
+			return HandlerFunc(func(w ResponseWriter, r *Request) {
+				order = append(order, name)
+				h.ServeGemini(w, r)
+			})
+		}
+	}
+
+	h := Chain(tag("a"), tag("b"), tag("c"))(HandlerFunc(func(ResponseWriter, *Request) {
+		order = append(order, "handler")
+	}))
+
+	h.ServeGemini(&recorder{}, mustRequest(t, "gemini://example.com/"))
+
+	if got := strings.Join(order, ","); got != "a,b,c,handler" {
+		t.Errorf("got order %s", got)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mw := Recover(slog.New(slog.NewJSONHandler(buf, nil)))
+
+	rec := &recorder{}
+	mw(HandlerFunc(func(ResponseWriter, *Request) {
+		panic("boom")
+	})).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != TemporaryFailure {
+		t.Errorf("got status %d", rec.status)
+	}
+
+	if !strings.Contains(buf.String(), "boom") || !strings.Contains(buf.String(), "goroutine") {
+		t.Errorf("expected the panic and stack to be logged: %s", buf.String())
+	}
+
+	rec = &recorder{}
+	mw(HandlerFunc(func(w ResponseWriter, _ *Request) {
+		w.WriteHeader(Success, "text/plain")
+		panic("late")
+	})).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != Success || rec.meta != "text/plain" {
+		t.Errorf("header written before the panic must be kept, got '%d %s'", rec.status, rec.meta)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	writeErr := make(chan error, 1)
+
+	slow := HandlerFunc(func(w ResponseWriter, r *Request) {
+		<-r.Context().Done()
+
+		_, err := w.Write([]byte("late"))
+		writeErr <- err
+	})
+
+	rec := &recorder{}
+	Timeout(10*time.Millisecond)(slow).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != TemporaryFailure || rec.body.Len() != 0 {
+		t.Errorf("got '%d %s' '%s'", rec.status, rec.meta, rec.body.String())
+	}
+
+	if err := <-writeErr; !errors.Is(err, ErrHandlerTimeout) {
+		t.Errorf("expected ErrHandlerTimeout, got %v", err)
+	}
+
+	rec = &recorder{}
+	Timeout(time.Second)(HandlerFunc(func(w ResponseWriter, _ *Request) {
+		w.WriteHeader(Success, "text/plain")
+		w.Write([]byte("fast"))
+	})).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != Success || rec.meta != "text/plain" || rec.body.String() != "fast" {
+		t.Errorf("got '%d %s' '%s'", rec.status, rec.meta, rec.body.String())
+	}
+}
+
+func TestTimeoutPanicsReachRecover(t *testing.T) {
+	rec := &recorder{}
+	h := Chain(Recover(slog.New(NoopHandler{})), Timeout(time.Second))(HandlerFunc(func(ResponseWriter, *Request) {
+		panic("boom")
+	}))
+
+	h.ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != TemporaryFailure {
+		t.Errorf("got status %d", rec.status)
+	}
+}
+
+func TestMiddlewareNilLogger(t *testing.T) {
+	rec := &recorder{}
+	Recover(nil)(HandlerFunc(func(ResponseWriter, *Request) {
+		panic("boom")
+	})).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != TemporaryFailure {
+		t.Errorf("Recover: got status %d", rec.status)
+	}
+
+	rec = &recorder{}
+	StrictHeaders(nil)(HandlerFunc(func(w ResponseWriter, _ *Request) {
+		w.Write([]byte("body"))
+	})).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+	if rec.status != TemporaryFailure {
+		t.Errorf("StrictHeaders: got status %d", rec.status)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	ids := make(map[string]struct{})
+
+	h := RequestID(HandlerFunc(func(_ ResponseWriter, r *Request) {
+		ids[RequestIDFromContext(r.Context())] = struct{}{}
+	}))
+
+	for range 3 {
+		h.ServeGemini(&recorder{}, mustRequest(t, "gemini://example.com/"))
+	}
+
+	if len(ids) != 3 {
+		t.Errorf("expected 3 distinct ids, got %v", ids)
+	}
+
+	if _, ok := ids[""]; ok {
+		t.Errorf("expected non-empty ids")
+	}
+}
+
+func TestStrictHeaders(t *testing.T) {
+	buf := &bytes.Buffer{}
+	mw := StrictHeaders(slog.New(slog.NewJSONHandler(buf, nil)))
+
+	cases := []struct {
+		name    string
+		handler HandlerFunc
+		status  StatusCode
+		body    string
+		logged  string
+	}{
+		{"one header", func(w ResponseWriter, _ *Request) {
+			w.WriteHeader(Success, DefaultMIME)
+			w.Write([]byte("ok"))
+		}, Success, "ok", ""},
+		{"two headers", func(w ResponseWriter, _ *Request) {
+			w.WriteHeader(NotFound, "first")
+			w.WriteHeader(Success, DefaultMIME)
+		}, NotFound, "", "more than one header"},
+		{"body first", func(w ResponseWriter, _ *Request) {
+			if _, err := w.Write([]byte("oops")); !errors.Is(err, ErrMissingHeader) {
+				t.Errorf("expected ErrMissingHeader, got %v", err)
+			}
+		}, TemporaryFailure, "", "before the header"},
+		{"no header", func(ResponseWriter, *Request) {}, TemporaryFailure, "", "no header"},
+	}
+
+	for _, c := range cases {
+		buf.Reset()
+
+		rec := &recorder{}
+		mw(c.handler).ServeGemini(rec, mustRequest(t, "gemini://example.com/"))
+
+		if rec.status != c.status || rec.body.String() != c.body {
+			t.Errorf("%s: got '%d %s' '%s'", c.name, rec.status, rec.meta, rec.body.String())
+		}
+
+		if c.logged != "" && !strings.Contains(buf.String(), c.logged) {
+			t.Errorf("%s: expected '%s' to be logged, got %s", c.name, c.logged, buf.String())
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package libgemini
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ServeMux is a request multiplexer. It matches the host and path of each
+// request against a list of registered patterns and calls the handler of
+// the most specific match.
+//
+// Patterns have the form [HOST]/[PATH]:
+//
+//   - "/about" matches exactly /about.
+//   - "/docs/" ends in a slash, so it matches /docs/ and everything below it.
+//     A request for /docs is redirected to /docs/ with RedirectPermanent.
+//   - "/users/{id}" matches a single segment, available via
+//     Request.PathValue("id").
+//   - "/files/{path...}" matches the rest of the path.
+//   - "example.com/" only matches requests for the host example.com.
+//
+// Patterns with a host take precedence over those without one. Otherwise,
+// the pattern with the most literal segments wins, then the one with the
+// most segments, then exact patterns win over those ending in a slash.
+// Requests matching no pattern are answered with NotFound.
+type ServeMux struct {
+	mu      sync.RWMutex
+	entries []*muxEntry
+}
+
+// NewServeMux allocates and returns a new ServeMux.
+func NewServeMux() *ServeMux {
+	return &ServeMux{}
+}
+
+type muxEntry struct {
+	pattern  string
+	host     string
+	segments []string
+	literals int
+	subtree  bool
+	handler  Handler
+}
+
+const (
+	wildcardOpen  = "{"
+	wildcardClose = "}"
+	wildcardRest  = "..."
+)
+
+// Handle registers handler for pattern. It panics if the pattern is
+// invalid or already registered.
+func (mux *ServeMux) Handle(pattern string, handler Handler) {
+	if handler == nil {
+		panic("libgemini: nil handler")
+	}
+
+	entry, err := parsePattern(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("libgemini: %v", err))
+	}
+
+	entry.handler = handler
+
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+
+	for _, existing := range mux.entries {
+		if existing.pattern == pattern {
+			panic(fmt.Sprintf("libgemini: multiple registrations for '%s'", pattern))
+		}
+	}
+
+	mux.entries = append(mux.entries, entry)
+}
+
+// HandleFunc registers fn for pattern.
+func (mux *ServeMux) HandleFunc(pattern string, fn func(w ResponseWriter, r *Request)) {
+	mux.Handle(pattern, HandlerFunc(fn))
+}
+
+func parsePattern(pattern string) (*muxEntry, error) {
+	index := strings.Index(pattern, "/")
+	if index == -1 {
+		return nil, fmt.Errorf("invalid pattern '%s': missing path", pattern)
+	}
+
+	entry := &muxEntry{
+		pattern: pattern,
+		host:    strings.ToLower(pattern[:index]),
+	}
+
+	path := pattern[index:]
+	entry.subtree = strings.HasSuffix(path, "/")
+
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return entry, nil
+	}
+
+	entry.segments = strings.Split(trimmed, "/")
+	last := len(entry.segments) - 1
+	names := make(map[string]struct{})
+
+	for k, seg := range entry.segments {
+		name, isWildcard, isRest := parseSegment(seg)
+
+		switch {
+		case !isWildcard:
+			entry.literals++
+
+			continue
+		case name == "":
+			return nil, fmt.Errorf("invalid pattern '%s': empty wildcard", pattern)
+		case isRest && (k != last || entry.subtree):
+			return nil, fmt.Errorf("invalid pattern '%s': %s wildcard must be last", pattern, wildcardRest)
+		}
+
+		if _, dup := names[name]; dup {
+			return nil, fmt.Errorf("invalid pattern '%s': duplicate wildcard '%s'", pattern, name)
+		}
+
+		names[name] = struct{}{}
+	}
+
+	return entry, nil
+}
+
+// parseSegment reports whether seg is a wildcard, returning its name.
+func parseSegment(seg string) (string, bool, bool) {
+	if !strings.HasPrefix(seg, wildcardOpen) || !strings.HasSuffix(seg, wildcardClose) {
+		return "", false, false
+	}
+
+	name := seg[len(wildcardOpen) : len(seg)-len(wildcardClose)]
+	if rest, isRest := strings.CutSuffix(name, wildcardRest); isRest {
+		return rest, true, true
+	}
+
+	return name, true, false
+}
+
+// match reports whether the path segments match the entry, returning the
+// values of its wildcards.
+func (entry *muxEntry) match(segs []string) (map[string]string, bool) {
+	n := len(entry.segments)
+
+	switch {
+	case entry.subtree && len(segs) <= n:
+		return nil, false
+	case !entry.subtree && len(segs) < n:
+		return nil, false
+	}
+
+	params := make(map[string]string)
+
+	for k, seg := range entry.segments {
+		name, isWildcard, isRest := parseSegment(seg)
+
+		switch {
+		case isRest:
+			params[name] = strings.Join(segs[k:], "/")
+
+			return params, true
+		case isWildcard:
+			if segs[k] == "" {
+				return nil, false
+			}
+
+			params[name] = segs[k]
+		case seg != segs[k]:
+			return nil, false
+		}
+	}
+
+	if !entry.subtree && len(segs) != n {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// moreSpecific reports whether entry takes precedence over other.
+func (entry *muxEntry) moreSpecific(other *muxEntry) bool {
+	if other == nil {
+		return true
+	}
+
+	if entry.literals != other.literals {
+		return entry.literals > other.literals
+	}
+
+	if len(entry.segments) != len(other.segments) {
+		return len(entry.segments) > len(other.segments)
+	}
+
+	return !entry.subtree && other.subtree
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		path = "/"
+	}
+
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func (mux *ServeMux) lookup(host, path string) (*muxEntry, map[string]string) {
+	segs := splitPath(path)
+
+	for _, byHost := range []bool{true, false} {
+		var (
+			best       *muxEntry
+			bestParams map[string]string
+		)
+
+		for _, entry := range mux.entries {
+			if (entry.host != "") != byHost || (byHost && entry.host != host) {
+				continue
+			}
+
+			params, ok := entry.match(segs)
+			if ok && entry.moreSpecific(best) {
+				best, bestParams = entry, params
+			}
+		}
+
+		if best != nil {
+			return best, bestParams
+		}
+	}
+
+	return nil, nil
+}
+
+// Handler returns the handler to use for r and the pattern it matched. If
+// the request should be redirected to add a trailing slash, a handler
+// doing so is returned. If nothing matches, a NotFound handler and an
+// empty pattern are returned.
+func (mux *ServeMux) Handler(r *Request) (Handler, string) {
+	handler, pattern, _ := mux.handler(r)
+
+	return handler, pattern
+}
+
+func (mux *ServeMux) handler(r *Request) (Handler, string, map[string]string) {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+
+	host := strings.ToLower(r.u.Hostname())
+	path := r.u.Path
+
+	entry, params := mux.lookup(host, path)
+
+	if !strings.HasSuffix(path, "/") {
+		alt, _ := mux.lookup(host, path+"/")
+
+		isRoot := alt != nil && alt.subtree && len(alt.segments) == len(splitPath(path))
+		if isRoot && (entry == nil || (entry.subtree && alt.moreSpecific(entry))) {
+			u := r.URL()
+			u.Path += "/"
+			u.RawPath = ""
+
+			return redirectHandler(u.String(), RedirectPermanent), alt.pattern, nil
+		}
+	}
+
+	if entry == nil {
+		return NotFoundHandler(), "", nil
+	}
+
+	return entry.handler, entry.pattern, params
+}
+
+// ServeGemini dispatches the request to the handler whose pattern most
+// closely matches it.
+func (mux *ServeMux) ServeGemini(w ResponseWriter, r *Request) {
+	handler, _, params := mux.handler(r)
+
+	if len(params) > 0 {
+		r2 := *r
+		r2.params = params
+		r = &r2
+	}
+
+	handler.ServeGemini(w, r)
+}
+
+func redirectHandler(target string, status StatusCode) Handler {
+	return HandlerFunc(func(w ResponseWriter, _ *Request) {
+		Redirect(w, target, status)
+	})
+}
+
+// PathValue returns the value of the named wildcard matched by a ServeMux
+// pattern, or an empty string if there is none.
+func (r Request) PathValue(name string) string {
+	return r.params[name]
+}
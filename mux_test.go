@@ -0,0 +1,79 @@
+package libgemini
+
+import "testing"
+
+func TestServeMux(t *testing.T) {
+	mux := NewServeMux()
+
+	handle := func(name string) HandlerFunc {
+		return func(w ResponseWriter, r *Request) {
+			w.Write([]byte(name + " " + r.PathValue("id") + r.PathValue("path")))
+		}
+	}
+
+	mux.Handle("/", handle("root"))
+	mux.Handle("/about", handle("about"))
+	mux.Handle("/docs/", handle("docs"))
+	mux.Handle("/users/{id}", handle("user"))
+	mux.Handle("/users/me", handle("me"))
+	mux.Handle("/files/{path...}", handle("files"))
+	mux.Handle("example.org/", handle("host"))
+
+	cases := []struct {
+		url    string
+		status StatusCode
+		want   string
+	}{
+		{"gemini://example.com/", Success, "root "},
+		{"gemini://example.com/about", Success, "about "},
+		{"gemini://example.com/about/", Success, "root "},
+		{"gemini://example.com/docs/a/b", Success, "docs "},
+		{"gemini://example.com/docs", RedirectPermanent, "gemini://example.com/docs/"},
+		{"gemini://example.com/users/42", Success, "user 42"},
+		{"gemini://example.com/users/me", Success, "me "},
+		{"gemini://example.com/files/a/b.gmi", Success, "files a/b.gmi"},
+		{"gemini://EXAMPLE.org/about", Success, "host "},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(tt *testing.T) {
+			rec := &recorder{}
+			mux.ServeGemini(rec, mustRequest(tt, c.url))
+
+			got := rec.body.String()
+			if c.status != Success {
+				got = rec.meta
+			}
+
+			if rec.status != c.status || got != c.want {
+				tt.Fatalf("got (%d, %q), want (%d, %q)", rec.status, got, c.status, c.want)
+			}
+		})
+	}
+
+	t.Run("it answers NotFound for misses", func(tt *testing.T) {
+		mux := NewServeMux()
+		mux.Handle("/only", handle("only"))
+
+		rec := &recorder{}
+		mux.ServeGemini(rec, mustRequest(tt, "gemini://example.com/other"))
+
+		if rec.status != NotFound {
+			tt.Fatalf("got status %d, want %d", rec.status, NotFound)
+		}
+	})
+
+	t.Run("it panics on invalid patterns", func(tt *testing.T) {
+		for _, pattern := range []string{"nopath", "/a/{}", "/{rest...}/b", "/{x}/{x}", "/about"} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						tt.Fatalf("expected panic for '%s'", pattern)
+					}
+				}()
+
+				mux.Handle(pattern, handle("x"))
+			}()
+		}
+	})
+}
@@ -17,9 +17,15 @@ type Options struct {
 	RCFilepath      string
 	DumpHeaders     string
 	Trace           string
+	RecordPath      string
 	Timeout         time.Duration
 	FollowRedirects bool
 	Insecure        bool
+
+	// SkipConfig ignores the geminirc file and the LIBGEMINI_* environment
+	// variables, leaving only the defaults and the options passed to
+	// NewClient.
+	SkipConfig bool
 }
 
 const (
@@ -44,12 +50,14 @@ const (
 	EnvDumpHeaders     = "LIBGEMINI_DUMP_HEADERS"
 	EnvTrace           = "LIBGEMINI_TRACE"
 	EnvInsecure        = "LIBGEMINI_INSECURE"
+	EnvRecord          = "LIBGEMINI_RECORD"
 	KeyRC              = "RC"
 	KeyFollowRedirects = "FollowRedirects"
 	KeyStorePath       = "StorePath"
 	KeyDumpHeaders     = "DumpHeaders"
 	KeyTrace           = "Trace"
 	KeyInsecure        = "Insecure"
+	KeyRecord          = "Record"
 )
 
 type strOrBool struct {
@@ -87,6 +95,10 @@ func envOpts() map[string]strOrBool {
 		opts[KeyInsecure] = strOrBool{b: toBool(v)}
 	}
 
+	if v, set := os.LookupEnv(EnvRecord); set {
+		opts[KeyRecord] = strOrBool{s: v}
+	}
+
 	return opts
 }
 
@@ -96,6 +108,7 @@ const (
 	ConfigDumpHeaders     = "dump-headers"
 	ConfigTrace           = "trace"
 	ConfigInsecure        = "insecure"
+	ConfigRecord          = "record"
 )
 
 func configOpts(contents string) map[string]strOrBool {
@@ -111,6 +124,7 @@ func configOpts(contents string) map[string]strOrBool {
 		ConfigStore:           KeyStorePath,
 		ConfigDumpHeaders:     KeyDumpHeaders,
 		ConfigTrace:           KeyTrace,
+		ConfigRecord:          KeyRecord,
 	}
 
 	for _, line := range strings.Split(contents, "\n") {
@@ -129,7 +143,7 @@ func configOpts(contents string) map[string]strOrBool {
 		switch optName {
 		case ConfigFollowRedirects, ConfigInsecure:
 			opts[lookup[optName]] = strOrBool{b: true}
-		case ConfigStore, ConfigDumpHeaders, ConfigTrace:
+		case ConfigStore, ConfigDumpHeaders, ConfigTrace, ConfigRecord:
 			val := strings.TrimSpace(strings.Join(parts[1:], " "))
 			if val == "" {
 				continue
@@ -162,6 +176,8 @@ func mergeOpts(base Options, applyOpts ...map[string]strOrBool) Options {
 				base.Trace = val.s
 			case KeyInsecure:
 				base.Insecure = val.b
+			case KeyRecord:
+				base.RecordPath = val.s
 			}
 		}
 	}
@@ -198,6 +214,22 @@ func WithInsecure() OptsFn {
 	}
 }
 
+// WithoutConfig makes the client ignore the geminirc file and the
+// environment, for instance in tests. See: Options.SkipConfig.
+func WithoutConfig() OptsFn {
+	return func(opts *Options) {
+		opts.SkipConfig = true
+	}
+}
+
+// WithRecord makes the client append each exchange to the cassette at
+// path. See: Cassette.
+func WithRecord(path string) OptsFn {
+	return func(opts *Options) {
+		opts.RecordPath = path
+	}
+}
+
 //go:embed data/geminirc
 var stubRCFile []byte
 
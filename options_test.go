@@ -15,6 +15,7 @@ func TestConfigFile(t *testing.T) {
 		KeyTrace:           {s: "/tmp/libgemini-trace.txt"},
 		KeyInsecure:        {b: true},
 		KeyStorePath:       {s: "~/.config/libgemini/known_hosts"},
+		KeyRecord:          {s: "/tmp/libgemini-cassette.jsonl"},
 	}
 
 	for key, val := range want {
@@ -0,0 +1,160 @@
+package libgemini
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultProxyTimeout = 30 * time.Second
+
+// ReverseProxy forwards requests to an upstream Gemini server using Client,
+// streaming the upstream's response back to the client.
+//
+// The request's path is appended to the path of Upstream, so a proxy is
+// usually mounted with StripPrefix:
+//
+//	upstream, _ := url.Parse("gemini://internal.lan:1966/app/")
+//	mux.Handle("/app/", StripPrefix("/app", &ReverseProxy{Upstream: upstream, Client: client}))
+//
+// Redirects to resources below Upstream are rewritten to relative targets,
+// so they point back at the proxy. Other redirects are passed on unchanged.
+// If the upstream cannot be reached, times out or sends an invalid header,
+// the request is answered with ProxyError.
+type ReverseProxy struct {
+	// Upstream is the base URL requests are forwarded to.
+	Upstream *url.URL
+
+	// Client is used to make upstream requests. It must trust the upstream's
+	// certificate.
+	Client *Client
+
+	// Timeout bounds the whole upstream exchange, DefaultProxyTimeout if zero.
+	Timeout time.Duration
+
+	// Logger receives upstream errors. Nothing is logged if nil.
+	Logger *slog.Logger
+}
+
+func (p *ReverseProxy) ServeGemini(w ResponseWriter, r *Request) {
+	logger := loggerOrNoop(p.Logger)
+
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = DefaultProxyTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	target := p.upstreamURL(r.u)
+	source := target.String()
+
+	upReq, err := NewRequest(source)
+	if err != nil {
+		logger.Error("invalid upstream request", "upstream", source, "error", err)
+		Error(w, ProxyError, "proxy error")
+
+		return
+	}
+
+	resp, err := p.Client.StreamWithContext(ctx, upReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			logger.Error("upstream timed out", "upstream", source, "error", err)
+			Error(w, ProxyError, "upstream timeout")
+
+			return
+		}
+
+		logger.Error("upstream request failed", "upstream", source, "error", err)
+		Error(w, ProxyError, "proxy error")
+
+		return
+	}
+	defer resp.Body.Close()
+
+	header := resp.Header
+	if err := validHeader(header.Status, header.Meta); err != nil {
+		logger.Error("invalid upstream response", "upstream", source, "error", err)
+		Error(w, ProxyError, "proxy error")
+
+		return
+	}
+
+	if header.Status.IsRedirect() {
+		header.Meta = p.rewriteRedirect(r.u, target, header.Meta)
+	}
+
+	w.WriteHeader(header.Status, header.Meta)
+
+	if !header.Status.IsSuccess() {
+		return
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		logger.Error("could not relay upstream response", "upstream", source, "error", err)
+	}
+}
+
+// upstreamURL returns the upstream URL for the request URL u.
+func (p *ReverseProxy) upstreamURL(u *url.URL) *url.URL {
+	target := *p.Upstream
+	target.Path = p.basePath() + ensureLeadingSlash(u.Path)
+	target.RawPath = ""
+	target.RawQuery = u.RawQuery
+	target.Fragment = ""
+
+	return &target
+}
+
+func (p *ReverseProxy) basePath() string {
+	return strings.TrimSuffix(p.Upstream.Path, "/")
+}
+
+// rewriteRedirect maps a redirect target sent by the upstream for the
+// request upstream, made on behalf of the request u, to a target relative
+// to u.
+func (p *ReverseProxy) rewriteRedirect(u, upstream *url.URL, meta string) string {
+	ref, err := url.Parse(meta)
+	if err != nil {
+		return meta
+	}
+
+	dest := upstream.ResolveReference(ref)
+	if dest.Scheme != p.Upstream.Scheme || !strings.EqualFold(dest.Host, p.Upstream.Host) {
+		return meta
+	}
+
+	base := p.basePath()
+
+	rest, found := strings.CutPrefix(dest.Path, base+"/")
+	if !found {
+		return meta
+	}
+
+	// NOTE: the upstream mirrors the proxy's layout below the base path, so
+	// walking up from the request's directory lands on the proxy's base.
+	depth := strings.Count(ensureLeadingSlash(u.Path), "/") - 1
+
+	prefix := "./"
+	if depth > 0 {
+		prefix = strings.Repeat("../", depth)
+	}
+
+	rewritten := &url.URL{Path: rest, RawQuery: dest.RawQuery}
+
+	return prefix + rewritten.String()
+}
+
+func ensureLeadingSlash(upath string) string {
+	if strings.HasPrefix(upath, "/") {
+		return upath
+	}
+
+	return "/" + upath
+}
@@ -0,0 +1,81 @@
+package libgemini
+
+import (
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestReverseProxy(t *testing.T) {
+	upMux := NewServeMux()
+	upMux.HandleFunc("/base/hello", func(w ResponseWriter, r *Request) {
+		w.Write([]byte("hello " + r.URL().RawQuery))
+	})
+	upMux.HandleFunc("/base/a/b", func(w ResponseWriter, _ *Request) {
+		Redirect(w, "/base/c?x=1", RedirectTemporary)
+	})
+	upMux.HandleFunc("/base/external", func(w ResponseWriter, _ *Request) {
+		Redirect(w, "gemini://example.com/", RedirectPermanent)
+	})
+	upMux.HandleFunc("/base/missing", func(w ResponseWriter, _ *Request) {
+		Error(w, NotFound, "nope")
+	})
+	upMux.HandleFunc("/base/slow", func(w ResponseWriter, r *Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	upMux.HandleFunc("/base/dir/", func(w ResponseWriter, _ *Request) {
+		w.Write([]byte("dir"))
+	})
+
+	upAddr := startTestServer(t, &Server{Handler: upMux})
+
+	upstream, err := url.Parse("gemini://" + upAddr + "/base/")
+	if err != nil {
+		t.Fatalf("could not parse upstream: %v", err)
+	}
+
+	// NOTE: nothing listens on a port once its listener is closed.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	deadAddr := l.Addr().String()
+	l.Close()
+
+	deadUpstream, _ := url.Parse("gemini://" + deadAddr + "/")
+	client := testClient(t)
+
+	mux := NewServeMux()
+	mux.Handle("/app/", StripPrefix("/app", &ReverseProxy{Upstream: upstream, Client: client, Timeout: 500 * time.Millisecond}))
+	mux.Handle("/dead/", StripPrefix("/dead", &ReverseProxy{Upstream: deadUpstream, Client: client}))
+
+	addr := startTestServer(t, &Server{Handler: mux})
+
+	cases := []struct {
+		path   string
+		status StatusCode
+		meta   string
+		body   string
+	}{
+		{"/app/hello?q", Success, DefaultMIME, "hello q"},
+		{"/app/a/b", RedirectTemporary, "../c?x=1", ""},
+		{"/app/dir", RedirectPermanent, "./dir/", ""},
+		{"/app/external", RedirectPermanent, "gemini://example.com/", ""},
+		{"/app/missing", NotFound, "nope", ""},
+		{"/app/slow", ProxyError, "upstream timeout", ""},
+		{"/dead/hello", ProxyError, "proxy error", ""},
+	}
+
+	for _, c := range cases {
+		resp, _ := rawGet(t, addr, "localhost", "gemini://localhost"+c.path)
+		if resp.Header.Status != c.status || resp.Header.Meta != c.meta || string(resp.Content) != c.body {
+			t.Errorf("%s: got '%d %s' '%s', want '%d %s' '%s'",
+				c.path, resp.Header.Status, resp.Header.Meta, resp.Content, c.status, c.meta, c.body)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package libgemini
+
+import (
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit configures a token bucket: it holds up to Burst tokens and is
+// refilled with Rate tokens per second. Every request takes one token.
+// A Rate of zero or less disables limiting, and a Burst below one is
+// treated as one.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitStore holds the state of the token buckets, allowing it to be
+// shared between servers.
+type RateLimitStore interface {
+	// Take removes a token from the bucket for key. If none is available,
+	// it returns how long to wait until one is.
+	Take(key string, limit RateLimit, now time.Time) (time.Duration, error)
+}
+
+// RateLimitKeyFunc returns the key identifying the client making a request.
+type RateLimitKeyFunc func(r *Request) string
+
+// KeyByRemoteIP identifies clients by their IP address.
+func KeyByRemoteIP(r *Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// KeyByClientCertificate identifies clients by the fingerprint of their
+// certificate, falling back to their IP address.
+func KeyByClientCertificate(r *Request) string {
+	if fingerprint := r.ClientFingerprint(); fingerprint != "" {
+		return fingerprint
+	}
+
+	return KeyByRemoteIP(r)
+}
+
+// LimitRate returns a middleware answering SlowDown, with the number of
+// seconds to wait as meta, once a client has used up its tokens.
+// If store is nil, an in-memory store is used. If key is nil, clients are
+// identified by KeyByRemoteIP. Requests are let through if the store fails.
+func LimitRate(limit RateLimit, store RateLimitStore, key RateLimitKeyFunc) Middleware {
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+
+	if key == nil {
+		key = KeyByRemoteIP
+	}
+
+	return func(h Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			wait, err := store.Take(key(r), limit, time.Now())
+			if err == nil && wait > 0 {
+				seconds := max(int(math.Ceil(wait.Seconds())), 1)
+				Error(w, SlowDown, strconv.Itoa(seconds))
+
+				return
+			}
+
+			h.ServeGemini(w, r)
+		})
+	}
+}
+
+const rateLimitSweepInterval = time.Minute
+
+// MemoryRateLimitStore is an in-memory RateLimitStore. Buckets that have
+// refilled completely are periodically removed. Each limit has its own
+// buckets, so a store can be shared by middlewares using different limits.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[bucketKey]*tokenBucket
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	limit RateLimit
+	key   string
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryRateLimitStore allocates and returns an empty store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[bucketKey]*tokenBucket)}
+}
+
+func (store *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) (time.Duration, error) {
+	if limit.Rate <= 0 {
+		return 0, nil
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	limit.Burst = max(limit.Burst, 1)
+
+	store.sweep(now)
+
+	bk := bucketKey{limit: limit, key: key}
+
+	bucket, found := store.buckets[bk]
+	if !found {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		store.buckets[bk] = bucket
+	}
+
+	bucket.refill(limit, now)
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+
+		return 0, nil
+	}
+
+	missing := 1 - bucket.tokens
+
+	return time.Duration(missing / limit.Rate * float64(time.Second)), nil
+}
+
+func (bucket *tokenBucket) refill(limit RateLimit, now time.Time) {
+	elapsed := now.Sub(bucket.last).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+elapsed*limit.Rate)
+		bucket.last = now
+	}
+}
+
+// sweep removes the buckets that would be full by now, as they are
+// indistinguishable from new ones. Each bucket is refilled using its own
+// limit.
+func (store *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+
+	store.lastSweep = now
+
+	for bk, bucket := range store.buckets {
+		bucket.refill(bk.limit, now)
+
+		if bucket.tokens >= float64(bk.limit.Burst) {
+			delete(store.buckets, bk)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package libgemini
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	limit := RateLimit{Rate: 2, Burst: 3}
+	now := time.Now()
+
+	for k := range limit.Burst {
+		if wait, _ := store.Take("client", limit, now); wait != 0 {
+			t.Fatalf("(take %d) expected no wait, got %s", k, wait)
+		}
+	}
+
+	if wait, _ := store.Take("client", limit, now); wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %s", wait)
+	}
+
+	if wait, _ := store.Take("other", limit, now); wait != 0 {
+		t.Fatalf("buckets are not per key, got wait %s", wait)
+	}
+
+	if wait, _ := store.Take("client", limit, now.Add(time.Second/2)); wait != 0 {
+		t.Fatalf("bucket did not refill, got wait %s", wait)
+	}
+}
+
+func TestMemoryRateLimitStoreZeroBurst(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	limit := RateLimit{Rate: 100, Burst: 0}
+	now := time.Now()
+
+	if wait, _ := store.Take("client", limit, now); wait != 0 {
+		t.Fatalf("expected a burst of 1, got wait %s", wait)
+	}
+
+	if wait, _ := store.Take("client", limit, now); wait != 10*time.Millisecond {
+		t.Fatalf("expected to wait 10ms, got %s", wait)
+	}
+
+	if wait, _ := store.Take("client", limit, now.Add(time.Hour)); wait != 0 {
+		t.Fatalf("bucket did not refill, got wait %s", wait)
+	}
+}
+
+func TestMemoryRateLimitStoreSharedLimits(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	slow := RateLimit{Rate: 0.001, Burst: 1}
+	fast := RateLimit{Rate: 100, Burst: 1}
+	now := time.Now()
+
+	if wait, _ := store.Take("a", slow, now); wait != 0 {
+		t.Fatalf("expected no wait, got %s", wait)
+	}
+
+	// NOTE: a sweep triggered by the fast limit must not refill, and so
+	// remove, the slow limit's buckets.
+	later := now.Add(2 * rateLimitSweepInterval)
+	if wait, _ := store.Take("b", fast, later); wait != 0 {
+		t.Fatalf("expected no wait, got %s", wait)
+	}
+
+	if wait, _ := store.Take("a", slow, later); wait == 0 {
+		t.Fatalf("the slow bucket was refilled by another limit")
+	}
+
+	if wait, _ := store.Take("a", fast, later); wait != 0 {
+		t.Fatalf("limits share buckets, got wait %s", wait)
+	}
+}
+
+func TestLimitRate(t *testing.T) {
+	handler := LimitRate(RateLimit{Rate: 0.1, Burst: 1}, nil, nil)(HandlerFunc(func(w ResponseWriter, _ *Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	req := mustRequest(t, "gemini://example.com/")
+	req.RemoteAddr = "192.0.2.1:5000"
+
+	want := []struct {
+		status StatusCode
+		meta   string
+	}{
+		{Success, DefaultMIME},
+		{SlowDown, "10"},
+	}
+
+	for k, w := range want {
+		rec := &recorder{}
+		handler.ServeGemini(rec, req)
+
+		if rec.status != w.status || rec.meta != w.meta {
+			t.Fatalf("(request %d) got (%d, %q), want (%d, %q)", k, rec.status, rec.meta, w.status, w.meta)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package libgemini
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -30,7 +33,7 @@ func NewRequest(rawURL string) (Request, error) {
 		uri.Host = fmt.Sprintf("%s:%d", uri.Host, geminiPort)
 	}
 
-	req := Request{uri}
+	req := Request{u: uri}
 	if err := req.Valid(); err != nil {
 		return req, err
 	}
@@ -39,14 +42,36 @@ func NewRequest(rawURL string) (Request, error) {
 }
 
 // Request is a simple struct which wraps around a url.URL, providing a few methods around it.
+//
+// It is used both for outgoing requests made by a Client and for incoming
+// requests handled by a Server, in which case the Server sets RemoteAddr
+// and TLS.
 type Request struct {
 	u *url.URL
+
+	RemoteAddr string
+	TLS        *tls.ConnectionState
+
+	ctx    context.Context //nolint:containedctx
+	params map[string]string
+	titan  *TitanUpload
 }
 
 func (r Request) String() string {
 	return r.u.String()
 }
 
+// URL returns a copy of the request's URL.
+func (r Request) URL() *url.URL {
+	if r.u == nil {
+		return nil
+	}
+
+	u := *r.u
+
+	return &u
+}
+
 const maxRequestSize = 1024
 
 func (r Request) Valid() error {
@@ -62,6 +87,31 @@ func (r Request) Valid() error {
 	return nil
 }
 
+var ErrInvalidRequest = errors.New("invalid request")
+
+// parseRequestURL parses the URL of an incoming request, which must be
+// absolute and must not contain userinfo or a fragment.
+func parseRequestURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not parse URL (%s): %w", ErrInvalidRequest, rawURL, err)
+	}
+
+	if !u.IsAbs() || u.Host == "" {
+		return nil, fmt.Errorf("%w: not an absolute URL (%s)", ErrInvalidRequest, rawURL)
+	}
+
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: URL contains userinfo", ErrInvalidRequest)
+	}
+
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("%w: URL contains a fragment", ErrInvalidRequest)
+	}
+
+	return u, nil
+}
+
 const (
 	CRLF = "\r\n"
 )
@@ -1,10 +1,12 @@
 package libgemini
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"strconv"
 )
 
@@ -12,6 +14,25 @@ type Response struct {
 	Header  Header
 	MIME    string
 	Content []byte
+
+	// req is the request that produced this response.
+	req Request
+
+	// fingerprint is the fingerprint of the server's certificate.
+	fingerprint string
+}
+
+// Request returns the request that produced the response. It is only
+// set for responses returned by a Client.
+func (resp Response) Request() Request {
+	return resp.req
+}
+
+// PeerFingerprint returns the fingerprint of the certificate presented by
+// the server, in the format used by the TOFU store. It is only set for
+// responses returned by a Client.
+func (resp Response) PeerFingerprint() string {
+	return resp.fingerprint
 }
 
 type Header struct {
@@ -73,6 +94,61 @@ func ReadResponse(r io.Reader) (Response, error) {
 	}
 }
 
+// ReadHeader will read the response header from r, leaving r positioned
+// at the start of the body.
+func ReadHeader(r *bufio.Reader) (Header, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return Header{}, fmt.Errorf("error reading header: %w", err)
+	}
+
+	if n := len(line); n > maxResponseSize {
+		return Header{}, fmt.Errorf("max header size of %d bytes exceeded, got %d bytes", maxResponseSize, n)
+	}
+
+	header, _, err := parseHeader(line)
+	if err != nil {
+		return header, fmt.Errorf("error parsing header: %w", err)
+	}
+
+	return header, nil
+}
+
+// StreamResponse is a response whose body is read from the connection as
+// it arrives. See: Client.StreamWithContext.
+type StreamResponse struct {
+	Header Header
+	Body   io.ReadCloser
+
+	// req is the request that produced this response.
+	req Request
+}
+
+// Request returns the request that produced the response.
+func (resp *StreamResponse) Request() Request {
+	return resp.req
+}
+
+type streamBody struct {
+	r    *bufio.Reader
+	conn net.Conn
+	stop func() bool
+}
+
+func (body *streamBody) Read(p []byte) (int, error) {
+	return body.r.Read(p) //nolint:wrapcheck
+}
+
+func (body *streamBody) Close() error {
+	body.stop()
+
+	if err := body.conn.Close(); err != nil {
+		return fmt.Errorf("error closing connection: %w", err)
+	}
+
+	return nil
+}
+
 func parseHeader(respBytes []byte) (Header, int, error) {
 	index := bytes.Index(respBytes, []byte{'\r', '\n'})
 	if index == -1 {
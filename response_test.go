@@ -1,9 +1,11 @@
 package libgemini
 
 import (
+	"bufio"
 	"bytes"
 	_ "embed"
 	"encoding/json"
+	"io"
 	"strings"
 	"testing"
 )
@@ -44,3 +46,25 @@ func TestReadResponse(t *testing.T) {
 func trim(b []byte) []byte {
 	return []byte(strings.TrimSpace(string(b)))
 }
+
+func TestReadHeader(t *testing.T) {
+	r := bufio.NewReader(bytes.NewReader(testRawResponse))
+
+	header, err := ReadHeader(r)
+	if err != nil {
+		t.Fatalf("could not read header: %v", err)
+	}
+
+	if header.Status != Success || header.Meta != "text/gemini" {
+		t.Fatalf("unexpected header: %+v", header)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("could not read body: %v", err)
+	}
+
+	if !bytes.HasPrefix(body, []byte("# Project Gemini")) {
+		t.Fatalf("body does not start after the header: %q", body[:20])
+	}
+}
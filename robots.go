@@ -0,0 +1,121 @@
+package libgemini
+
+import (
+	"io"
+	"strings"
+)
+
+// Virtual user agents used by Gemini crawlers in robots.txt, in addition
+// to the wildcard matching every crawler.
+const (
+	UserAgentAll        = "*"
+	UserAgentArchiver   = "archiver"
+	UserAgentIndexer    = "indexer"
+	UserAgentResearcher = "researcher"
+	UserAgentWebproxy   = "webproxy"
+)
+
+const (
+	RobotsPath      = "/robots.txt"
+	FaviconPath     = "/favicon.txt"
+	SecurityTxtPath = "/.well-known/security.txt"
+	plainTextMIME   = "text/plain; charset=utf-8"
+)
+
+// RobotsRule disallows crawling paths starting with any of Disallow, for
+// the given user agents. A rule without paths allows everything.
+type RobotsRule struct {
+	UserAgents []string
+	Disallow   []string
+}
+
+// Robots is a structured robots.txt file.
+type Robots struct {
+	Rules []RobotsRule
+}
+
+// Disallow adds paths to the rule for the single user agent userAgent,
+// creating the rule if needed.
+func (robots *Robots) Disallow(userAgent string, paths ...string) {
+	for k, rule := range robots.Rules {
+		if len(rule.UserAgents) == 1 && rule.UserAgents[0] == userAgent {
+			robots.Rules[k].Disallow = append(robots.Rules[k].Disallow, paths...)
+
+			return
+		}
+	}
+
+	robots.Rules = append(robots.Rules, RobotsRule{UserAgents: []string{userAgent}, Disallow: paths})
+}
+
+// String renders the rules in the robots.txt format.
+func (robots Robots) String() string {
+	bdr := &strings.Builder{}
+
+	for k, rule := range robots.Rules {
+		if k > 0 {
+			bdr.WriteString("\n")
+		}
+
+		for _, userAgent := range rule.UserAgents {
+			bdr.WriteString("User-agent: " + userAgent + "\n")
+		}
+
+		if len(rule.Disallow) == 0 {
+			bdr.WriteString("Disallow:\n")
+		}
+
+		for _, path := range rule.Disallow {
+			bdr.WriteString("Disallow: " + path + "\n")
+		}
+	}
+
+	return bdr.String()
+}
+
+// WellKnownFiles holds the contents of files served at conventional paths.
+// Empty or nil fields are not served.
+type WellKnownFiles struct {
+	// Robots is served as /robots.txt.
+	Robots *Robots
+
+	// Favicon is served as /favicon.txt. It should be a single emoji.
+	Favicon string
+
+	// SecurityTxt is served as /.well-known/security.txt.
+	SecurityTxt string
+}
+
+// ServeWellKnown returns middleware serving the configured well-known files
+// as text/plain, passing other requests on to the wrapped handler. Paths of
+// files that are not configured are passed on too.
+func ServeWellKnown(files WellKnownFiles) Middleware {
+	contents := make(map[string]string)
+
+	if files.Robots != nil {
+		contents[RobotsPath] = files.Robots.String()
+	}
+
+	if files.Favicon != "" {
+		contents[FaviconPath] = strings.TrimSpace(files.Favicon) + "\n"
+	}
+
+	if files.SecurityTxt != "" {
+		contents[SecurityTxtPath] = files.SecurityTxt
+	}
+
+	return func(h Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			content, ok := contents[r.u.Path]
+			if !ok {
+				h.ServeGemini(w, r)
+
+				return
+			}
+
+			w.WriteHeader(Success, plainTextMIME)
+
+			_, _ = io.WriteString(w, content) //nolint:errcheck
+		})
+	}
+}
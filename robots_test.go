@@ -0,0 +1,66 @@
+package libgemini
+
+import "testing"
+
+func TestRobots(t *testing.T) {
+	robots := &Robots{}
+	robots.Disallow(UserAgentAll, "/cgi-bin/")
+	robots.Disallow(UserAgentArchiver, "/drafts/")
+	robots.Disallow(UserAgentArchiver, "/private/")
+	robots.Rules = append(robots.Rules, RobotsRule{UserAgents: []string{UserAgentIndexer, UserAgentResearcher}})
+
+	want := "User-agent: *\n" +
+		"Disallow: /cgi-bin/\n" +
+		"\n" +
+		"User-agent: archiver\n" +
+		"Disallow: /drafts/\n" +
+		"Disallow: /private/\n" +
+		"\n" +
+		"User-agent: indexer\n" +
+		"User-agent: researcher\n" +
+		"Disallow:\n"
+
+	if got := robots.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestServeWellKnown(t *testing.T) {
+	robots := &Robots{}
+	robots.Disallow(UserAgentWebproxy, "/")
+
+	h := ServeWellKnown(WellKnownFiles{
+		Robots:      robots,
+		Favicon:     "🚀",
+		SecurityTxt: "Contact: mailto:security@example.com\n",
+	})(HandlerFunc(func(w ResponseWriter, _ *Request) {
+		w.Write([]byte("next"))
+	}))
+
+	cases := []struct {
+		path string
+		meta string
+		body string
+	}{
+		{RobotsPath, plainTextMIME, "User-agent: webproxy\nDisallow: /\n"},
+		{FaviconPath, plainTextMIME, "🚀\n"},
+		{SecurityTxtPath, plainTextMIME, "Contact: mailto:security@example.com\n"},
+		{"/index.gmi", DefaultMIME, "next"},
+	}
+
+	for _, c := range cases {
+		rec := &recorder{}
+		h.ServeGemini(rec, mustRequest(t, "gemini://example.com"+c.path))
+
+		if rec.status != Success || rec.meta != c.meta || rec.body.String() != c.body {
+			t.Errorf("%s: got '%d %s' %q", c.path, rec.status, rec.meta, rec.body.String())
+		}
+	}
+
+	rec := &recorder{}
+	ServeWellKnown(WellKnownFiles{})(NotFoundHandler()).ServeGemini(rec, mustRequest(t, "gemini://example.com"+RobotsPath))
+
+	if rec.status != NotFound {
+		t.Errorf("unconfigured files must be passed on, got '%d %s'", rec.status, rec.meta)
+	}
+}
@@ -0,0 +1,108 @@
+package libgemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"time"
+)
+
+const DefaultSCGITimeout = 30 * time.Second
+
+// SCGIHandler forwards requests to an SCGI backend and relays the Gemini
+// response it writes back to the client.
+//
+// The backend receives the same environment as CGI scripts, with the
+// request's path in PATH_INFO. If the backend cannot be reached or times
+// out, the request is answered with ProxyError. If it writes an invalid
+// header, the request is answered with CGIError.
+type SCGIHandler struct {
+	// Network is either "tcp" or "unix".
+	Network string
+
+	// Address is the backend's address, or the socket's path.
+	Address string
+
+	// Timeout bounds the whole exchange with the backend,
+	// DefaultSCGITimeout if zero.
+	Timeout time.Duration
+
+	// Logger receives backend errors. Nothing is logged if nil.
+	Logger *slog.Logger
+}
+
+func (h *SCGIHandler) ServeGemini(w ResponseWriter, r *Request) {
+	logger := loggerOrNoop(h.Logger)
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultSCGITimeout
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	d := net.Dialer{}
+
+	conn, err := d.DialContext(ctx, h.Network, h.Address)
+	if err != nil {
+		logger.Error("could not reach SCGI backend", "address", h.Address, "error", err)
+		Error(w, ProxyError, "backend unavailable")
+
+		return
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline) //nolint:errcheck
+	}
+
+	env := cgiEnv(r, "", r.u.Path)
+	delete(env, "PATH")
+
+	if _, err := conn.Write(scgiRequest(env)); err != nil {
+		logger.Error("could not write SCGI request", "address", h.Address, "error", err)
+		Error(w, ProxyError, "backend unavailable")
+
+		return
+	}
+
+	streamCGIResponse(w, bufio.NewReader(conn), logger, h.Address)
+}
+
+// scgiRequest encodes the headers as a netstring, as required by SCGI.
+// CONTENT_LENGTH must come first and Gemini requests have no body.
+func scgiRequest(env map[string]string) []byte {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	headers := &bytes.Buffer{}
+	writeHeader := func(key, val string) {
+		headers.WriteString(key)
+		headers.WriteByte(0)
+		headers.WriteString(val)
+		headers.WriteByte(0)
+	}
+
+	writeHeader("CONTENT_LENGTH", "0")
+	writeHeader("SCGI", "1")
+
+	for _, key := range keys {
+		writeHeader(key, env[key])
+	}
+
+	netstring := &bytes.Buffer{}
+	fmt.Fprintf(netstring, "%d:", headers.Len())
+	netstring.Write(headers.Bytes())
+	netstring.WriteByte(',')
+
+	return netstring.Bytes()
+}
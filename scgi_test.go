@@ -0,0 +1,111 @@
+package libgemini
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readSCGIRequest parses the netstring sent by SCGIHandler.
+func readSCGIRequest(r *bufio.Reader) (map[string]string, error) {
+	size, err := r.ReadString(':')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(size, ":"))
+	if err != nil {
+		return nil, err
+	}
+
+	p := make([]byte, n+1)
+	if _, err := io.ReadFull(r, p); err != nil {
+		return nil, err
+	}
+
+	parts := bytes.Split(bytes.TrimSuffix(p[:n], []byte{0}), []byte{0})
+	headers := make(map[string]string)
+
+	for k := 0; k+1 < len(parts); k += 2 {
+		headers[string(parts[k])] = string(parts[k+1])
+	}
+
+	return headers, nil
+}
+
+func startSCGIBackend(t *testing.T, network, address string, respond func(map[string]string) string) {
+	t.Helper()
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			headers, err := readSCGIRequest(bufio.NewReader(conn))
+			if err == nil {
+				io.WriteString(conn, respond(headers))
+			}
+
+			conn.Close()
+		}
+	}()
+}
+
+func TestSCGIHandler(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "scgi.sock")
+
+	startSCGIBackend(t, "unix", socket, func(headers map[string]string) string {
+		if headers["PATH_INFO"] == "/broken" {
+			return "not a gemini header"
+		}
+
+		return fmt.Sprintf("20 text/plain\r\n%s %s %s", headers["SCGI"], headers["PATH_INFO"], headers["QUERY_STRING"])
+	})
+
+	handler := &SCGIHandler{Network: "unix", Address: socket, Timeout: time.Second}
+
+	t.Run("it relays the backend's response", func(tt *testing.T) {
+		rec := &recorder{}
+		handler.ServeGemini(rec, mustRequest(tt, "gemini://example.com/app/page?x=1"))
+
+		if rec.status != Success || rec.meta != "text/plain" || rec.body.String() != "1 /app/page x=1" {
+			tt.Fatalf("got (%d, %q, %q)", rec.status, rec.meta, rec.body.String())
+		}
+	})
+
+	t.Run("it answers CGIError on invalid responses", func(tt *testing.T) {
+		rec := &recorder{}
+		handler.ServeGemini(rec, mustRequest(tt, "gemini://example.com/broken"))
+
+		if rec.status != CGIError {
+			tt.Fatalf("got status %d, want %d", rec.status, CGIError)
+		}
+	})
+
+	t.Run("it answers ProxyError when the backend is down", func(tt *testing.T) {
+		down := &SCGIHandler{Network: "unix", Address: filepath.Join(tt.TempDir(), "missing.sock")}
+
+		rec := &recorder{}
+		down.ServeGemini(rec, mustRequest(tt, "gemini://example.com/"))
+
+		if rec.status != ProxyError {
+			tt.Fatalf("got status %d, want %d", rec.status, ProxyError)
+		}
+	})
+}
@@ -0,0 +1,637 @@
+package libgemini
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Handler responds to a Gemini request.
+//
+// ServeGemini should write the response header and body to the
+// ResponseWriter and then return. The connection is closed once it returns.
+type Handler interface {
+	ServeGemini(w ResponseWriter, r *Request)
+}
+
+// HandlerFunc is an adapter allowing ordinary functions to be used as
+// handlers.
+type HandlerFunc func(w ResponseWriter, r *Request)
+
+// ServeGemini calls fn(w, r).
+func (fn HandlerFunc) ServeGemini(w ResponseWriter, r *Request) {
+	fn(w, r)
+}
+
+// ResponseWriter is used by a Handler to construct a response.
+type ResponseWriter interface {
+	// WriteHeader sends the response header. Only the first call has any
+	// effect.
+	WriteHeader(status StatusCode, meta string)
+
+	// Write writes body data. If WriteHeader has not been called yet,
+	// Write calls WriteHeader(Success, "text/gemini") first. Body data is
+	// only allowed on successful responses.
+	Write(p []byte) (int, error)
+}
+
+var (
+	ErrServerClosed    = errors.New("server closed")
+	ErrBodyNotAllowed  = errors.New("response status does not allow a body")
+	ErrNoCertificates  = errors.New("no certificates configured")
+	ErrInvalidResponse = errors.New("invalid response header")
+)
+
+const (
+	DefaultServerAddr  = ":1965"
+	DefaultReadTimeout = 30 * time.Second
+	DefaultMIME        = "text/gemini"
+	DefaultHostname    = "localhost"
+)
+
+// Server is a Gemini server.
+//
+// If no certificates are configured, through TLSConfig or
+// ListenAndServeTLS, a self-signed certificate for each of Hostnames is
+// loaded from CertDir, or generated and saved there on first start.
+type Server struct {
+	// Addr is the TCP address to listen on, DefaultServerAddr if empty.
+	Addr string
+
+	// Handler is invoked for every request. If nil, every request is
+	// answered with NotFound.
+	Handler Handler
+
+	// TLSConfig is cloned and used for every listener.
+	TLSConfig *tls.Config
+
+	// Hostnames are the hosts certificates are generated for when none
+	// are configured, DefaultHostname if empty.
+	Hostnames []string
+
+	// CertDir is where generated certificates are stored, as
+	// <hostname>.crt and <hostname>.key. DefaultCertDir if empty.
+	CertDir string
+
+	// ReadTimeout bounds the TLS handshake and reading the request line.
+	// DefaultReadTimeout is used if zero.
+	ReadTimeout time.Duration
+
+	// WriteTimeout bounds the time taken to handle a request, including
+	// reading titan uploads, and write the response. Zero means no timeout.
+	WriteTimeout time.Duration
+
+	// ErrorLog receives errors from accepting connections and from
+	// handlers. Nothing is logged if nil.
+	ErrorLog *slog.Logger
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	reloader  *CertificateReloader
+	closed    atomic.Bool
+	ctx       context.Context //nolint:containedctx
+	cancel    context.CancelFunc
+}
+
+// ListenAndServe listens on srv.Addr and serves requests using
+// srv.TLSConfig.
+func (srv *Server) ListenAndServe() error {
+	if srv.closed.Load() {
+		return ErrServerClosed
+	}
+
+	addr := srv.Addr
+	if addr == "" {
+		addr = DefaultServerAddr
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on '%s': %w", addr, err)
+	}
+
+	return srv.Serve(l)
+}
+
+// ListenAndServeTLS works like ListenAndServe, loading the certificate and
+// key from the given files. The files are watched, and the certificate is
+// reloaded when they change. See: ReloadCertificates.
+func (srv *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	reloader, err := NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	reloader.Logger = srv.ErrorLog
+
+	cfg := &tls.Config{MinVersion: minTLSVersion}
+	if srv.TLSConfig != nil {
+		cfg = srv.TLSConfig.Clone()
+	}
+
+	cfg.Certificates = nil
+	cfg.GetCertificate = reloader.GetCertificate
+	srv.TLSConfig = cfg
+
+	srv.mu.Lock()
+	srv.reloader = reloader
+	srv.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go reloader.Watch(ctx, 0)
+
+	return srv.ListenAndServe()
+}
+
+// ReloadCertificates reloads the certificate files passed to
+// ListenAndServeTLS, for instance on SIGHUP. New connections use the new
+// certificate, while connections in progress are not affected.
+func (srv *Server) ReloadCertificates() error {
+	srv.mu.Lock()
+	reloader := srv.reloader
+	srv.mu.Unlock()
+
+	if reloader == nil {
+		return fmt.Errorf("%w: not serving certificate files", ErrNoCertificates)
+	}
+
+	return reloader.Reload()
+}
+
+// Serve accepts connections on l, wrapping them with TLS, and serves
+// requests on them. It always returns a non-nil error; after Close it
+// returns ErrServerClosed.
+func (srv *Server) Serve(l net.Listener) error {
+	cfg, err := srv.tlsConfig()
+	if err != nil {
+		l.Close()
+
+		return err
+	}
+
+	tlsListener := tls.NewListener(l, cfg)
+
+	ctx, ok := srv.trackListener(tlsListener)
+	if !ok {
+		tlsListener.Close()
+
+		return ErrServerClosed
+	}
+	defer srv.untrackListener(tlsListener)
+
+	for {
+		conn, err := tlsListener.Accept()
+		if err != nil {
+			if srv.closed.Load() {
+				return ErrServerClosed
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				srv.logger().Error("accept error", "error", err)
+
+				continue
+			}
+
+			return fmt.Errorf("error accepting connection: %w", err)
+		}
+
+		if !srv.trackConn(conn) {
+			conn.Close()
+
+			return ErrServerClosed
+		}
+
+		go srv.serveConn(ctx, conn)
+	}
+}
+
+// Close immediately closes all listeners and connections. See: Shutdown
+// for a graceful alternative.
+func (srv *Server) Close() error {
+	srv.closed.Store(true)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.cancel != nil {
+		srv.cancel()
+	}
+
+	errs := make([]error, 0)
+
+	for l := range srv.listeners {
+		if err := l.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for conn := range srv.conns {
+		conn.Close()
+	}
+
+	return errors.Join(errs...)
+}
+
+const shutdownPollInterval = 10 * time.Millisecond
+
+// Shutdown gracefully shuts down the server: it closes all listeners, then
+// waits for connections in progress to be served before returning. If ctx
+// is done first, remaining connections are closed and the context's error
+// is returned.
+//
+// NOTE: connections that have not sent their request yet are waited for
+// too, for at most ReadTimeout.
+func (srv *Server) Shutdown(ctx context.Context) error {
+	srv.closed.Store(true)
+
+	errs := make([]error, 0)
+
+	srv.mu.Lock()
+	for l := range srv.listeners {
+		if err := l.Close(); err != nil {
+			errs = append(errs, err)
+		}
+
+		delete(srv.listeners, l)
+	}
+	srv.mu.Unlock()
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for !srv.idle() {
+		select {
+		case <-ctx.Done():
+			srv.Close()
+
+			return ctx.Err() //nolint:wrapcheck
+		case <-ticker.C:
+		}
+	}
+
+	errs = append(errs, srv.Close())
+
+	return errors.Join(errs...)
+}
+
+func (srv *Server) idle() bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return len(srv.conns) == 0
+}
+
+func (srv *Server) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if srv.TLSConfig != nil {
+		cfg = srv.TLSConfig.Clone()
+	}
+
+	if len(cfg.Certificates) == 0 && cfg.GetCertificate == nil && cfg.GetConfigForClient == nil {
+		certs, err := srv.autoCertificates()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNoCertificates, err)
+		}
+
+		cfg.Certificates = certs
+	}
+
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = minTLSVersion
+	}
+
+	// NOTE: client certificates are self-signed, so they are requested but
+	// never verified. Handlers decide what to do with them.
+	if cfg.ClientAuth == tls.NoClientCert {
+		cfg.ClientAuth = tls.RequestClientCert
+	}
+
+	return cfg, nil
+}
+
+func (srv *Server) autoCertificates() ([]tls.Certificate, error) {
+	hostnames := srv.Hostnames
+	if len(hostnames) == 0 {
+		hostnames = []string{DefaultHostname}
+	}
+
+	dir := srv.CertDir
+	if dir == "" {
+		defaultDir, err := DefaultCertDir()
+		if err != nil {
+			return nil, err
+		}
+
+		dir = defaultDir
+	}
+
+	return autoCertificates(dir, hostnames)
+}
+
+func (srv *Server) logger() *slog.Logger {
+	return loggerOrNoop(srv.ErrorLog)
+}
+
+// trackListener registers l, returning the context shared by all requests
+// served by srv. It returns false if the server is closed.
+func (srv *Server) trackListener(l net.Listener) (context.Context, bool) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.closed.Load() {
+		return nil, false
+	}
+
+	if srv.listeners == nil {
+		srv.listeners = make(map[net.Listener]struct{})
+	}
+
+	srv.listeners[l] = struct{}{}
+
+	if srv.ctx == nil {
+		srv.ctx, srv.cancel = context.WithCancel(context.Background())
+	}
+
+	return srv.ctx, true
+}
+
+func (srv *Server) untrackListener(l net.Listener) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	delete(srv.listeners, l)
+}
+
+func (srv *Server) trackConn(conn net.Conn) bool {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	if srv.closed.Load() {
+		return false
+	}
+
+	if srv.conns == nil {
+		srv.conns = make(map[net.Conn]struct{})
+	}
+
+	srv.conns[conn] = struct{}{}
+
+	return true
+}
+
+func (srv *Server) untrackConn(conn net.Conn) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	delete(srv.conns, conn)
+}
+
+func (srv *Server) serveConn(_ctx context.Context, conn net.Conn) {
+	defer srv.untrackConn(conn)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(_ctx)
+	defer cancel()
+
+	readTimeout := srv.ReadTimeout
+	if readTimeout == 0 {
+		readTimeout = DefaultReadTimeout
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(readTimeout)) //nolint:errcheck
+
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return
+	}
+
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		srv.logger().Debug("tls handshake failed", "remote", conn.RemoteAddr().String(), "error", err)
+
+		return
+	}
+
+	resp := newResponse(conn, srv.logger())
+
+	br := bufio.NewReader(conn)
+
+	req, err := ReadRequest(br)
+	if err != nil {
+		resp.WriteHeader(BadRequest, "bad request")
+		resp.finish()
+
+		return
+	}
+
+	_ = conn.SetDeadline(time.Time{}) //nolint:errcheck
+
+	// NOTE: this also bounds reading the body of titan uploads.
+	if srv.WriteTimeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(srv.WriteTimeout)) //nolint:errcheck
+	}
+
+	state := tlsConn.ConnectionState()
+	req.RemoteAddr = conn.RemoteAddr().String()
+	req.TLS = &state
+	req.ctx = ctx
+
+	handler := srv.Handler
+	if handler == nil {
+		handler = NotFoundHandler()
+	}
+
+	if srv.runHandler(handler, resp, req) {
+		resp.finish()
+	}
+}
+
+// runHandler calls handler, recovering from a panic: it is logged and false
+// is returned, in which case the connection is closed without flushing
+// what the handler wrote.
+func (srv *Server) runHandler(handler Handler, resp *response, req *Request) (ok bool) {
+	defer func() {
+		val := recover()
+		if val == nil {
+			return
+		}
+
+		srv.logger().Error(
+			"handler panicked",
+			"remote", req.RemoteAddr,
+			"url", req.u.String(),
+			"panic", fmt.Sprint(val),
+			"stack", string(debug.Stack()),
+		)
+
+		ok = false
+	}()
+
+	handler.ServeGemini(resp, req)
+
+	return true
+}
+
+// ReadRequest will read a request line, as sent by a client, from r.
+// The request must be an absolute URL without userinfo or fragment,
+// terminated by CRLF or a bare LF.
+//
+// For titan:// requests, the parameters are removed from the path and
+// the upload's body reads from r.
+func ReadRequest(r *bufio.Reader) (*Request, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, fmt.Errorf("error reading request: %w", err)
+	}
+
+	rawURL := strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r")
+
+	if n := len(rawURL); n > maxRequestSize {
+		return nil, fmt.Errorf("max request size of %d bytes exceeded, have %d bytes", maxRequestSize, n)
+	}
+
+	u, err := parseRequestURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Request{u: u}
+
+	if u.Scheme == titanScheme {
+		upload, err := parseTitanParams(u)
+		if err != nil {
+			return nil, err
+		}
+
+		upload.Body = &exactReader{r: r, n: upload.Size}
+		req.titan = upload
+	}
+
+	return req, nil
+}
+
+// Context returns the request's context. For incoming requests it is
+// canceled when the connection is closed.
+func (r Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+
+	return r.ctx
+}
+
+// WithContext returns a shallow copy of r with its context changed to ctx.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	r2 := *r
+	r2.ctx = ctx
+
+	return &r2
+}
+
+// response implements ResponseWriter on top of a connection.
+type response struct {
+	w           *bufio.Writer
+	logger      *slog.Logger
+	wroteHeader bool
+	bodyAllowed bool
+	err         error
+}
+
+func newResponse(w io.Writer, logger *slog.Logger) *response {
+	return &response{w: bufio.NewWriter(w), logger: logger}
+}
+
+func (resp *response) WriteHeader(status StatusCode, meta string) {
+	if resp.wroteHeader {
+		return
+	}
+
+	resp.wroteHeader = true
+
+	if err := validHeader(status, meta); err != nil {
+		resp.logger.Error("handler wrote an invalid header", "error", err)
+
+		status, meta = TemporaryFailure, "internal server error"
+	}
+
+	resp.bodyAllowed = status.IsSuccess()
+
+	_, resp.err = fmt.Fprintf(resp.w, "%d %s%s", status, meta, CRLF)
+}
+
+func (resp *response) Write(p []byte) (int, error) {
+	if !resp.wroteHeader {
+		resp.WriteHeader(Success, DefaultMIME)
+	}
+
+	if resp.err != nil {
+		return 0, resp.err
+	}
+
+	if !resp.bodyAllowed {
+		return 0, ErrBodyNotAllowed
+	}
+
+	return resp.w.Write(p) //nolint:wrapcheck
+}
+
+func (resp *response) finish() {
+	if !resp.wroteHeader {
+		resp.WriteHeader(Success, DefaultMIME)
+	}
+
+	_ = resp.w.Flush() //nolint:errcheck
+}
+
+const (
+	minStatusCode = 10
+	maxStatusCode = 69
+)
+
+func validHeader(status StatusCode, meta string) error {
+	if status < minStatusCode || status > maxStatusCode {
+		return fmt.Errorf("%w: status code %d out of range", ErrInvalidResponse, status)
+	}
+
+	if len(meta) > maxMetaSize {
+		return fmt.Errorf("%w: max meta size of %d bytes exceeded, got %d bytes", ErrInvalidResponse, maxMetaSize, len(meta))
+	}
+
+	if strings.ContainsAny(meta, "\r\n") {
+		return fmt.Errorf("%w: meta contains a line break", ErrInvalidResponse)
+	}
+
+	return nil
+}
+
+// Error replies with the given status and meta. Meta is typically an
+// error message for the user.
+func Error(w ResponseWriter, status StatusCode, meta string) {
+	w.WriteHeader(status, meta)
+}
+
+// NotFoundHandler returns a handler answering every request with NotFound.
+func NotFoundHandler() Handler {
+	return HandlerFunc(func(w ResponseWriter, _ *Request) {
+		Error(w, NotFound, "not found")
+	})
+}
+
+// Redirect replies with a redirect to target, which may be relative.
+// Status should be RedirectTemporary or RedirectPermanent.
+func Redirect(w ResponseWriter, target string, status StatusCode) {
+	w.WriteHeader(status, target)
+}
@@ -0,0 +1,324 @@
+package libgemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a ResponseWriter recording what handlers write.
+type recorder struct {
+	status      StatusCode
+	meta        string
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rec *recorder) WriteHeader(status StatusCode, meta string) {
+	if rec.wroteHeader {
+		return
+	}
+
+	rec.wroteHeader = true
+	rec.status = status
+	rec.meta = meta
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	rec.WriteHeader(Success, DefaultMIME)
+
+	return rec.body.Write(p)
+}
+
+func mustRequest(t *testing.T, rawURL string) *Request {
+	t.Helper()
+
+	u, err := parseRequestURL(rawURL)
+	if err != nil {
+		t.Fatalf("could not parse request URL: %v", err)
+	}
+
+	return &Request{u: u}
+}
+
+func testCertificate(t *testing.T, hosts ...string) tls.Certificate {
+	t.Helper()
+
+	cert, err := GenerateCertificate(CertificateOptions{Hosts: hosts, Validity: time.Hour})
+	if err != nil {
+		t.Fatalf("could not generate certificate: %v", err)
+	}
+
+	return cert
+}
+
+// startTestServer serves handler on a local listener, returning its address.
+func startTestServer(t *testing.T, srv *Server) string {
+	t.Helper()
+
+	if srv.TLSConfig == nil && srv.CertDir == "" {
+		srv.TLSConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{testCertificate(t, "localhost")},
+		}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	go srv.Serve(l) //nolint:errcheck
+
+	t.Cleanup(func() { srv.Close() })
+
+	return l.Addr().String()
+}
+
+func testClient(t *testing.T) *Client {
+	t.Helper()
+
+	t.Setenv(EnvRC, filepath.Join(t.TempDir(), "geminirc"))
+
+	client, err := NewClient(WithInMemoryStore(), WithInsecure())
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+
+	return client
+}
+
+func TestServer(t *testing.T) {
+	srv := &Server{
+		Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+			if r.URL().Path == "/fail" {
+				Error(w, PermanentFailure, "nope")
+
+				return
+			}
+
+			w.Write([]byte("hello " + r.URL().Path))
+		}),
+	}
+
+	addr := startTestServer(t, srv)
+	client := testClient(t)
+
+	t.Run("it serves requests", func(tt *testing.T) {
+		resp, err := client.Get("gemini://" + addr + "/world")
+		if err != nil {
+			tt.Fatalf("request failed: %v", err)
+		}
+
+		if resp.Header.Status != Success || resp.Header.Meta != DefaultMIME {
+			tt.Fatalf("unexpected header: %+v", resp.Header)
+		}
+
+		if got := string(resp.Content); got != "hello /world" {
+			tt.Fatalf("got body %q", got)
+		}
+	})
+
+	t.Run("it writes the handler's header", func(tt *testing.T) {
+		resp, err := client.Get("gemini://" + addr + "/fail")
+		if err != nil {
+			tt.Fatalf("request failed: %v", err)
+		}
+
+		if resp.Header.Status != PermanentFailure || resp.Header.Meta != "nope" || len(resp.Content) != 0 {
+			tt.Fatalf("unexpected response: %+v", resp)
+		}
+	})
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestServerRecoversFromPanics(t *testing.T) {
+	logs := &lockedBuffer{}
+	srv := &Server{
+		ErrorLog: slog.New(slog.NewJSONHandler(logs, nil)),
+		Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+			if r.URL().Path == "/panic" {
+				w.Write([]byte("partial"))
+				panic("boom")
+			}
+
+			w.Write([]byte("ok"))
+		}),
+	}
+
+	addr := startTestServer(t, srv)
+	client := testClient(t)
+
+	if resp, err := client.Get("gemini://" + addr + "/panic"); err == nil && resp.Header.Status != 0 {
+		t.Fatalf("expected the connection to be closed, got %+v", resp.Header)
+	}
+
+	resp, err := client.Get("gemini://" + addr + "/")
+	if err != nil || string(resp.Content) != "ok" {
+		t.Fatalf("server stopped serving after a panic: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "handler panicked") {
+		t.Errorf("panic was not logged: %s", logs.String())
+	}
+}
+
+func TestReadRequest(t *testing.T) {
+	cases := []struct {
+		raw   string
+		valid bool
+	}{
+		{"gemini://example.com/\r\n", true},
+		{"gemini://example.com/path?query\r\n", true},
+		{"/relative\r\n", false},
+		{"gemini://user@example.com/\r\n", false},
+		{"gemini://example.com/#fragment\r\n", false},
+		{"gemini://example.com/\n", true},
+		{"gemini://example.com/" + strings.Repeat("a", maxRequestSize) + "\r\n", false},
+		{"gemini://example.com/" + strings.Repeat("a", maxRequestSize-21) + "\n", true},
+		{"gemini://example.com/" + strings.Repeat("a", maxRequestSize-20) + "\n", false},
+	}
+
+	for _, c := range cases {
+		t.Run(strings.TrimSpace(c.raw), func(tt *testing.T) {
+			_, err := ReadRequest(bufio.NewReader(strings.NewReader(c.raw)))
+			if (err == nil) != c.valid {
+				tt.Fatalf("got error %v, want valid=%t", err, c.valid)
+			}
+		})
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	srv := &Server{
+		Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+			if r.URL().Path == "/stuck" {
+				<-r.Context().Done()
+
+				return
+			}
+
+			close(started)
+			<-release
+			w.Write([]byte("done"))
+		}),
+	}
+
+	addr := startTestServer(t, srv)
+	client := testClient(t)
+
+	type result struct {
+		resp Response
+		err  error
+	}
+
+	results := make(chan result, 1)
+
+	go func() {
+		resp, err := client.Get("gemini://" + addr + "/slow")
+		results <- result{resp, err}
+	}()
+
+	<-started
+
+	shutdownErr := make(chan error, 1)
+
+	go func() {
+		shutdownErr <- srv.Shutdown(context.Background())
+	}()
+
+	// NOTE: wait for the listener to be closed.
+	deadline := time.Now().Add(testTimeout)
+
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+
+		conn.Close()
+
+		if time.Now().After(deadline) {
+			t.Fatalf("server still accepts connections")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("shutdown returned before the request finished: %v", err)
+	default:
+	}
+
+	close(release)
+
+	res := <-results
+	if res.err != nil || string(res.resp.Content) != "done" {
+		t.Errorf("in-flight request failed: %v, '%s'", res.err, res.resp.Content)
+	}
+
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+
+	if err := srv.ListenAndServe(); !errors.Is(err, ErrServerClosed) {
+		t.Errorf("expected ErrServerClosed, got %v", err)
+	}
+}
+
+func TestServerShutdownDeadline(t *testing.T) {
+	handling := make(chan struct{})
+
+	srv := &Server{
+		Handler: HandlerFunc(func(_ ResponseWriter, r *Request) {
+			close(handling)
+			<-r.Context().Done()
+		}),
+	}
+
+	addr := startTestServer(t, srv)
+	client := testClient(t)
+
+	go client.Get("gemini://" + addr + "/") //nolint:errcheck
+
+	<-handling
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
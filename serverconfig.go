@@ -0,0 +1,492 @@
+package libgemini
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ServerConfig describes a server, as read from a serverrc file. See:
+// data/serverrc for the syntax, and NewServer to build a Server from it.
+type ServerConfig struct {
+	Listen    string
+	Hostnames []string
+	CertFile  string
+	KeyFile   string
+	CertDir   string
+
+	// Root is the document root served for every host, and HostRoots the
+	// document roots of specific hosts.
+	Root      string
+	HostRoots map[string]string
+
+	// CGI maps path prefixes to directories of CGI scripts. Relative
+	// directories read from a serverrc are made absolute.
+	CGI map[string]string
+
+	RateLimit       RateLimit
+	AccessLog       string
+	AccessLogFormat AccessLogFormat
+	ErrorLog        string
+
+	// Robots is served as /robots.txt if it has rules, Favicon as
+	// /favicon.txt, and the file at SecurityTxt as /.well-known/security.txt.
+	Robots      Robots
+	Favicon     string
+	SecurityTxt string
+}
+
+const (
+	EnvServerRC              = "LIBGEMINI_SERVERRC"
+	EnvServerListen          = "LIBGEMINI_SERVER_LISTEN"
+	EnvServerHostnames       = "LIBGEMINI_SERVER_HOSTNAMES"
+	EnvServerCert            = "LIBGEMINI_SERVER_CERT"
+	EnvServerKey             = "LIBGEMINI_SERVER_KEY"
+	EnvServerCertDir         = "LIBGEMINI_SERVER_CERT_DIR"
+	EnvServerRoot            = "LIBGEMINI_SERVER_ROOT"
+	EnvServerRateLimit       = "LIBGEMINI_SERVER_RATE_LIMIT"
+	EnvServerAccessLog       = "LIBGEMINI_SERVER_ACCESS_LOG"
+	EnvServerAccessLogFormat = "LIBGEMINI_SERVER_ACCESS_LOG_FORMAT"
+	EnvServerErrorLog        = "LIBGEMINI_SERVER_ERROR_LOG"
+	EnvServerFavicon         = "LIBGEMINI_SERVER_FAVICON"
+	EnvServerSecurityTxt     = "LIBGEMINI_SERVER_SECURITY_TXT"
+)
+
+const (
+	ConfigListen          = "listen"
+	ConfigHostname        = "hostname"
+	ConfigCert            = "cert"
+	ConfigKey             = "key"
+	ConfigCertDir         = "cert-dir"
+	ConfigRoot            = "root"
+	ConfigCGI             = "cgi"
+	ConfigRateLimit       = "rate-limit"
+	ConfigAccessLog       = "access-log"
+	ConfigAccessLogFormat = "access-log-format"
+	ConfigErrorLog        = "error-log"
+	ConfigRobotsDisallow  = "robots-disallow"
+	ConfigFavicon         = "favicon"
+	ConfigSecurityTxt     = "security-txt"
+	accessLogFormatJSON   = "json"
+	accessLogFormatCommon = "common"
+)
+
+// ParseServerConfig parses the contents of a serverrc file. Like the
+// geminirc, options are lines of the form "--key value", other lines are
+// ignored, and so are unknown options. Values made of a single path or
+// name may contain spaces.
+func ParseServerConfig(contents string) (ServerConfig, error) {
+	cfg := ServerConfig{}
+
+	for _, line := range strings.Split(contents, "\n") {
+		l := strings.TrimSpace(line)
+		if !strings.HasPrefix(l, "--") {
+			continue
+		}
+
+		key, value := cutField(l[2:])
+		if err := cfg.set(key, value); err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// cutField splits s around its first run of whitespace.
+func cutField(s string) (string, string) {
+	i := strings.IndexFunc(s, unicode.IsSpace)
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:i], strings.TrimSpace(s[i:])
+}
+
+// set applies the option key with the given value.
+func (cfg *ServerConfig) set(key, value string) error {
+	single := func() (string, error) {
+		if value == "" {
+			return "", fmt.Errorf("option '%s' expects a value", key)
+		}
+
+		return value, nil
+	}
+
+	var err error
+
+	switch key {
+	case ConfigListen:
+		cfg.Listen, err = single()
+	case ConfigHostname:
+		cfg.Hostnames = append(cfg.Hostnames, strings.Fields(value)...)
+	case ConfigCert:
+		cfg.CertFile, err = single()
+	case ConfigKey:
+		cfg.KeyFile, err = single()
+	case ConfigCertDir:
+		cfg.CertDir, err = single()
+	case ConfigRoot:
+		err = cfg.setRoot(value)
+	case ConfigCGI:
+		prefix, dir := cutField(value)
+		if dir == "" {
+			return fmt.Errorf("option '%s' expects a path prefix and a directory", key)
+		}
+
+		// NOTE: scripts run in their own directory, so relative
+		// directories are resolved now.
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("invalid CGI directory '%s': %w", dir, err)
+		}
+
+		if cfg.CGI == nil {
+			cfg.CGI = make(map[string]string)
+		}
+
+		cfg.CGI[prefix] = absDir
+	case ConfigRateLimit:
+		cfg.RateLimit, err = parseRateLimit(strings.Fields(value))
+	case ConfigAccessLog:
+		cfg.AccessLog, err = single()
+	case ConfigAccessLogFormat:
+		var format string
+		if format, err = single(); err == nil {
+			cfg.AccessLogFormat, err = parseAccessLogFormat(format)
+		}
+	case ConfigErrorLog:
+		cfg.ErrorLog, err = single()
+	case ConfigRobotsDisallow:
+		args := strings.Fields(value)
+		if len(args) == 0 {
+			return fmt.Errorf("option '%s' expects a user agent and paths", key)
+		}
+
+		cfg.Robots.Disallow(args[0], args[1:]...)
+	case ConfigFavicon:
+		cfg.Favicon, err = single()
+	case ConfigSecurityTxt:
+		cfg.SecurityTxt, err = single()
+	}
+
+	return err
+}
+
+// setRoot sets the document root of every host, or of a single host if the
+// value starts with one. Hosts never contain a slash, so a first word
+// containing one is part of the directory: a relative directory with
+// spaces should start with "./".
+func (cfg *ServerConfig) setRoot(value string) error {
+	if value == "" {
+		return fmt.Errorf("option '%s' expects an optional host and a directory", ConfigRoot)
+	}
+
+	host, dir := cutField(value)
+	if dir == "" || strings.Contains(host, "/") {
+		cfg.Root = value
+
+		return nil
+	}
+
+	if cfg.HostRoots == nil {
+		cfg.HostRoots = make(map[string]string)
+	}
+
+	cfg.HostRoots[strings.ToLower(host)] = dir
+
+	return nil
+}
+
+func parseRateLimit(args []string) (RateLimit, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return RateLimit{}, fmt.Errorf("option '%s' expects a rate and an optional burst", ConfigRateLimit)
+	}
+
+	rate, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("invalid rate '%s': %w", args[0], err)
+	}
+
+	limit := RateLimit{Rate: rate, Burst: 1}
+
+	if len(args) == 2 { //nolint:mnd
+		if limit.Burst, err = strconv.Atoi(args[1]); err != nil {
+			return RateLimit{}, fmt.Errorf("invalid burst '%s': %w", args[1], err)
+		}
+
+		if limit.Burst < 1 {
+			return RateLimit{}, fmt.Errorf("invalid burst '%s': must be at least 1", args[1])
+		}
+	}
+
+	return limit, nil
+}
+
+func parseAccessLogFormat(format string) (AccessLogFormat, error) {
+	switch format {
+	case accessLogFormatJSON:
+		return AccessLogJSON, nil
+	case accessLogFormatCommon:
+		return AccessLogCommon, nil
+	default:
+		return AccessLogJSON, fmt.Errorf("unknown access log format '%s'", format)
+	}
+}
+
+// applyEnv overrides cfg with the LIBGEMINI_SERVER_* environment variables
+// that are set. LIBGEMINI_SERVER_HOSTNAMES holds a comma or space
+// separated list, and LIBGEMINI_SERVER_ROOT sets the document root served
+// for every host.
+func (cfg *ServerConfig) applyEnv() error {
+	lookup := []struct {
+		env string
+		key string
+	}{
+		{EnvServerListen, ConfigListen},
+		{EnvServerHostnames, ConfigHostname},
+		{EnvServerCert, ConfigCert},
+		{EnvServerKey, ConfigKey},
+		{EnvServerCertDir, ConfigCertDir},
+		{EnvServerRoot, ConfigRoot},
+		{EnvServerRateLimit, ConfigRateLimit},
+		{EnvServerAccessLog, ConfigAccessLog},
+		{EnvServerAccessLogFormat, ConfigAccessLogFormat},
+		{EnvServerErrorLog, ConfigErrorLog},
+		{EnvServerFavicon, ConfigFavicon},
+		{EnvServerSecurityTxt, ConfigSecurityTxt},
+	}
+
+	for _, item := range lookup {
+		val, set := os.LookupEnv(item.env)
+		if !set {
+			continue
+		}
+
+		if item.key == ConfigHostname {
+			cfg.Hostnames = nil
+			val = strings.ReplaceAll(val, ",", " ")
+		}
+
+		if err := cfg.set(item.key, strings.TrimSpace(val)); err != nil {
+			return fmt.Errorf("invalid %s: %w", item.env, err)
+		}
+	}
+
+	return nil
+}
+
+//go:embed data/serverrc
+var stubServerRCFile []byte
+
+// LoadServerConfig reads the serverrc file and applies the environment
+// overrides. The file is, in order:
+//
+//  1. The location specified by the LIBGEMINI_SERVERRC environment variable.
+//  2. $HOME/.config/libgemini/serverrc
+//
+// If it does not exist, it is created from data/serverrc.
+func LoadServerConfig() (ServerConfig, error) {
+	fpath, set := os.LookupEnv(EnvServerRC)
+	if !set {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ServerConfig{}, fmt.Errorf("could not find home directory: %w", err)
+		}
+
+		fpath = filepath.Join(homeDir, ".config", "libgemini", "serverrc")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fpath), UserRWXAllNone); err != nil {
+		return ServerConfig{}, fmt.Errorf("could not create directory for '%s': %w", fpath, err)
+	}
+
+	writeIfNotExists(fpath, stubServerRCFile)
+
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return ServerConfig{}, fmt.Errorf("could not read '%s': %w", fpath, err)
+	}
+
+	cfg, err := ParseServerConfig(string(data))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid config '%s': %w", fpath, err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// NewServer builds a Server from cfg: document roots, either directories or
+// archives watched for changes (see: ArchiveFS), are served with
+// FileServer, CGI directories with CGIHandler, requests are rate limited
+// by IP address and logged to the access log. If a certificate file is
+// configured, it is watched and reloaded until ctx is done. Well-known
+// files, such as robots.txt, are served when configured. Log files are
+// closed once ctx is done.
+func (cfg ServerConfig) NewServer(ctx context.Context) (*Server, error) {
+	errorLog, err := NewLoggerFromPath(ctx, cfg.ErrorLog)
+	if err != nil {
+		return nil, err
+	}
+
+	accessLog, err := NewAccessLogger(ctx, cfg.AccessLog, cfg.AccessLogFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &Server{
+		Addr:      cfg.Listen,
+		Hostnames: cfg.Hostnames,
+		CertDir:   cfg.CertDir,
+		ErrorLog:  errorLog,
+	}
+
+	// NOTE: certificates and archives are only watched once the server is
+	// built, so that nothing is left polling if it fails.
+	var watchers []func(ctx context.Context, interval time.Duration)
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("options '%s' and '%s' must be set together", ConfigCert, ConfigKey)
+		}
+
+		reloader, err := NewCertificateReloader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		reloader.Logger = errorLog
+		srv.TLSConfig = &tls.Config{MinVersion: minTLSVersion, GetCertificate: reloader.GetCertificate}
+		watchers = append(watchers, reloader.Watch)
+	}
+
+	mux := NewServeMux()
+	hosts := []string{""}
+
+	if cfg.Root != "" {
+		fsys, err := rootFS(cfg.Root, errorLog, &watchers)
+		if err != nil {
+			return nil, err
+		}
+
+		mux.Handle("/", FileServer(fsys))
+	}
+
+	for host, root := range cfg.HostRoots {
+		fsys, err := rootFS(root, errorLog, &watchers)
+		if err != nil {
+			return nil, err
+		}
+
+		mux.Handle(host+"/", FileServer(fsys))
+		hosts = append(hosts, host)
+	}
+
+	mounted := make(map[string]bool)
+	if cfg.Root != "" || len(cfg.HostRoots) > 0 {
+		mounted["/"] = true
+	}
+
+	for rawPrefix, dir := range cfg.CGI {
+		prefix := cgiPrefix(rawPrefix)
+		if mounted[prefix] {
+			return nil, fmt.Errorf("option '%s': '%s' is already served", ConfigCGI, rawPrefix)
+		}
+
+		mounted[prefix] = true
+
+		cgi := StripPrefix(strings.TrimSuffix(prefix, "/"), &CGIHandler{Dir: dir, Logger: errorLog})
+
+		// NOTE: patterns with a host take precedence, so CGI directories are
+		// mounted for every host with its own document root.
+		for _, host := range hosts {
+			mux.Handle(host+prefix, cgi)
+		}
+	}
+
+	var handler Handler = mux
+
+	wellKnown, err := cfg.wellKnownFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	handler = ServeWellKnown(wellKnown)(handler)
+
+	if cfg.RateLimit.Rate > 0 {
+		handler = LimitRate(cfg.RateLimit, NewMemoryRateLimitStore(), KeyByRemoteIP)(handler)
+	}
+
+	srv.Handler = AccessLog(accessLog)(handler)
+
+	for _, watch := range watchers {
+		go watch(ctx, 0)
+	}
+
+	return srv, nil
+}
+
+// cgiPrefix returns prefix starting and ending with exactly one slash.
+func cgiPrefix(prefix string) string {
+	trimmed := strings.Trim(prefix, "/")
+	if trimmed == "" {
+		return "/"
+	}
+
+	return "/" + trimmed + "/"
+}
+
+func (cfg ServerConfig) wellKnownFiles() (WellKnownFiles, error) {
+	files := WellKnownFiles{Favicon: cfg.Favicon}
+
+	if len(cfg.Robots.Rules) > 0 {
+		files.Robots = &cfg.Robots
+	}
+
+	if cfg.SecurityTxt != "" {
+		data, err := os.ReadFile(cfg.SecurityTxt)
+		if err != nil {
+			return files, fmt.Errorf("could not read security.txt: %w", err)
+		}
+
+		files.SecurityTxt = string(data)
+	}
+
+	return files, nil
+}
+
+// rootFS returns the file system for a document root: the directory, or
+// the contents of the archive, at root. Archives are added to watchers.
+func rootFS(root string, logger *slog.Logger, watchers *[]func(context.Context, time.Duration)) (fs.FS, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document root: %w", err)
+	}
+
+	if info.IsDir() {
+		return os.DirFS(root), nil
+	}
+
+	afs, err := NewArchiveFS(root)
+	if err != nil {
+		return nil, err
+	}
+
+	afs.Logger = logger
+	*watchers = append(*watchers, afs.Watch)
+
+	return afs, nil
+}
@@ -0,0 +1,234 @@
+package libgemini
+
+import (
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/serverrc
+var serverRCTestFile []byte
+
+func TestServerConfigFile(t *testing.T) {
+	cfg, err := ParseServerConfig(string(serverRCTestFile))
+	if err != nil {
+		t.Fatalf("could not parse config: %v", err)
+	}
+
+	want := ServerConfig{
+		Listen:          "127.0.0.1:1966",
+		Hostnames:       []string{"localhost", "example.com"},
+		CertFile:        "/tmp/libgemini-server/cert.pem",
+		KeyFile:         "/tmp/libgemini-server/key.pem",
+		CertDir:         "/var/lib/gemini/certs",
+		Root:            "/srv/gemini",
+		HostRoots:       map[string]string{"example.com": "/srv/example.com"},
+		CGI:             map[string]string{"/cgi-bin/": "/srv/cgi-bin"},
+		RateLimit:       RateLimit{Rate: 2, Burst: 10},
+		AccessLog:       "/tmp/libgemini-server/access.log",
+		AccessLogFormat: AccessLogCommon,
+		ErrorLog:        StdErrKey,
+		Robots: Robots{Rules: []RobotsRule{
+			{UserAgents: []string{UserAgentAll}, Disallow: []string{"/cgi-bin/"}},
+			{UserAgents: []string{UserAgentArchiver}, Disallow: []string{"/drafts/", "/private/"}},
+		}},
+		Favicon:     "🚀",
+		SecurityTxt: "/tmp/libgemini-server/security.txt",
+	}
+
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v\nwant %+v", cfg, want)
+	}
+
+	stub, err := ParseServerConfig(string(stubServerRCFile))
+	if err != nil || !reflect.DeepEqual(stub, ServerConfig{}) {
+		t.Errorf("the stub serverrc must not set anything, got %+v, %v", stub, err)
+	}
+}
+
+func TestServerConfigSpaces(t *testing.T) {
+	cfg, err := ParseServerConfig(strings.Join([]string{
+		"--root /srv/my capsule",
+		"--root example.com  /srv/example capsule",
+		"--root ./my capsule",
+		"--cgi /cgi-bin/ /srv/cgi bin",
+		"--access-log /var/log/gemini/access log",
+	}, "\n"))
+	if err != nil {
+		t.Fatalf("could not parse config: %v", err)
+	}
+
+	want := ServerConfig{
+		Root:      "./my capsule",
+		HostRoots: map[string]string{"example.com": "/srv/example capsule"},
+		CGI:       map[string]string{"/cgi-bin/": "/srv/cgi bin"},
+		AccessLog: "/var/log/gemini/access log",
+	}
+
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v\nwant %+v", cfg, want)
+	}
+
+	cfg, _ = ParseServerConfig("--root /srv/my capsule")
+	if cfg.Root != "/srv/my capsule" {
+		t.Errorf("got root '%s'", cfg.Root)
+	}
+}
+
+func TestServerConfigErrors(t *testing.T) {
+	cases := []string{
+		"--listen",
+		"--root",
+		"--cgi /cgi-bin/",
+		"--rate-limit fast",
+		"--rate-limit 1 many",
+		"--rate-limit 2 0",
+		"--access-log-format xml",
+		"--robots-disallow",
+	}
+
+	for _, c := range cases {
+		if _, err := ParseServerConfig(c); err == nil {
+			t.Errorf("%s: expected an error", c)
+		}
+	}
+}
+
+func TestLoadServerConfig(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "serverrc")
+	t.Setenv(EnvServerRC, fpath)
+
+	if err := os.WriteFile(fpath, []byte("--listen :1965\n--hostname a.test\n--rate-limit 1\n"), UserRWAllR); err != nil {
+		t.Fatalf("could not write config: %v", err)
+	}
+
+	t.Setenv(EnvServerListen, "127.0.0.1:1967")
+	t.Setenv(EnvServerHostnames, "b.test, c.test")
+
+	cfg, err := LoadServerConfig()
+	if err != nil {
+		t.Fatalf("could not load config: %v", err)
+	}
+
+	if cfg.Listen != "127.0.0.1:1967" || !reflect.DeepEqual(cfg.Hostnames, []string{"b.test", "c.test"}) {
+		t.Errorf("environment overrides not applied: %+v", cfg)
+	}
+
+	if cfg.RateLimit != (RateLimit{Rate: 1, Burst: 1}) {
+		t.Errorf("got rate limit %+v", cfg.RateLimit)
+	}
+
+	t.Setenv(EnvServerRC, filepath.Join(t.TempDir(), "missing", "serverrc"))
+
+	if _, err := LoadServerConfig(); err != nil {
+		t.Fatalf("could not load default config: %v", err)
+	}
+
+	if _, err := os.Stat(os.Getenv(EnvServerRC)); err != nil {
+		t.Errorf("expected the stub config to be written: %v", err)
+	}
+}
+
+func TestServerConfigNewServer(t *testing.T) {
+	root, cgiDir := t.TempDir(), t.TempDir()
+	hostRoot := filepath.Join(t.TempDir(), "host.zip")
+
+	if err := os.WriteFile(filepath.Join(root, IndexFile), []byte("root"), UserRWAllR); err != nil {
+		t.Fatalf("could not write index: %v", err)
+	}
+
+	writeArchive(t, hostRoot, map[string]string{IndexFile: "host"})
+
+	writeScript(t, cgiDir, "hello", "#!/bin/sh\nprintf '20 text/plain\\r\\ncgi'\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := ServerConfig{
+		Hostnames: []string{"localhost"},
+		CertDir:   t.TempDir(),
+		Root:      root,
+		HostRoots: map[string]string{"a.test": hostRoot},
+		CGI:       map[string]string{"cgi-bin": cgiDir},
+		Favicon:   "🚀",
+	}
+
+	srv, err := cfg.NewServer(ctx)
+	if err != nil {
+		t.Fatalf("could not create server: %v", err)
+	}
+
+	addr := startTestServer(t, srv)
+
+	cases := []struct {
+		url  string
+		body string
+	}{
+		{"gemini://localhost/", "root"},
+		{"gemini://a.test/", "host"},
+		{"gemini://localhost/cgi-bin/hello", "cgi"},
+		{"gemini://a.test/cgi-bin/hello", "cgi"},
+		{"gemini://a.test/favicon.txt", "🚀\n"},
+	}
+
+	for _, c := range cases {
+		resp, _ := rawGet(t, addr, "localhost", c.url)
+		if resp.Header.Status != Success || string(resp.Content) != c.body {
+			t.Errorf("%s: got %+v '%s', want '%s'", c.url, resp.Header, resp.Content, c.body)
+		}
+	}
+
+	if _, err := (ServerConfig{CertFile: "cert.pem"}).NewServer(ctx); err == nil {
+		t.Errorf("expected an error when the key is missing")
+	}
+
+	if _, err := (ServerConfig{Root: root, CGI: map[string]string{"/": cgiDir}}).NewServer(ctx); err == nil {
+		t.Errorf("expected an error when CGI scripts are mounted over the document root")
+	}
+}
+
+func TestServerConfigCGIAtRoot(t *testing.T) {
+	cgiDir := t.TempDir()
+	writeScript(t, cgiDir, "hello", "#!/bin/sh\nprintf '20 text/plain\\r\\ncgi'\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := ServerConfig{
+		Hostnames: []string{"localhost"},
+		CertDir:   t.TempDir(),
+		CGI:       map[string]string{"/": cgiDir},
+	}
+
+	srv, err := cfg.NewServer(ctx)
+	if err != nil {
+		t.Fatalf("could not create server: %v", err)
+	}
+
+	addr := startTestServer(t, srv)
+
+	resp, _ := rawGet(t, addr, "localhost", "gemini://localhost/hello")
+	if resp.Header.Status != Success || string(resp.Content) != "cgi" {
+		t.Errorf("got %+v '%s'", resp.Header, resp.Content)
+	}
+}
+
+func TestServerConfigRelativeCGI(t *testing.T) {
+	cfg, err := ParseServerConfig("--cgi /cgi-bin/ cgi-bin")
+	if err != nil {
+		t.Fatalf("could not parse config: %v", err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("could not get working directory: %v", err)
+	}
+
+	if got, want := cfg.CGI["/cgi-bin/"], filepath.Join(wd, "cgi-bin"); got != want {
+		t.Errorf("got CGI directory '%s', want '%s'", got, want)
+	}
+}
@@ -18,6 +18,8 @@ const (
 	SlowDown                   StatusCode = 44
 	PermanentFailure           StatusCode = 50
 	NotFound                   StatusCode = 51
+	ProxyRequestRefused        StatusCode = 53
+	BadRequest                 StatusCode = 59
 	ClientCertificatedRequired StatusCode = 60
 	CertificateNotAuthorized   StatusCode = 61
 	CertificateNotValid        StatusCode = 62
@@ -27,6 +29,10 @@ func (code StatusCode) IsSuccess() bool {
 	return code >= Success && code < RedirectTemporary
 }
 
+func (code StatusCode) IsRedirect() bool {
+	return code >= RedirectTemporary && code < TemporaryFailure
+}
+
 func (code StatusCode) String() string {
 	name := ""
 
@@ -55,6 +61,10 @@ func (code StatusCode) String() string {
 		name = "Permanent Failure"
 	case NotFound:
 		name = "Not Found"
+	case ProxyRequestRefused:
+		name = "Proxy Request Refused"
+	case BadRequest:
+		name = "Bad Request"
 	case ClientCertificatedRequired:
 		name = "Client Certificate Required"
 	case CertificateNotAuthorized:
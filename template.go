@@ -0,0 +1,26 @@
+package libgemini
+
+import (
+	"bytes"
+
+	"github.com/aalbacetef/libgemini/gemtext"
+)
+
+// RenderTemplate executes tmpl with data and writes the result as a
+// text/gemini response. The output is buffered, so that if executing the
+// template fails the request is answered with TemporaryFailure instead of
+// a truncated page, and the error is returned.
+func RenderTemplate(w ResponseWriter, tmpl *gemtext.Template, data any) error {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		Error(w, TemporaryFailure, "internal server error")
+
+		return err //nolint:wrapcheck
+	}
+
+	w.WriteHeader(Success, gemtext.MIME)
+
+	_, err := w.Write(buf.Bytes())
+
+	return err //nolint:wrapcheck
+}
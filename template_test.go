@@ -0,0 +1,35 @@
+package libgemini
+
+import (
+	"testing"
+
+	"github.com/aalbacetef/libgemini/gemtext"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tmpl := gemtext.Must(gemtext.New("page").Parse("# Hello\n{{.Name}}\n"))
+
+	rec := &recorder{}
+	if err := RenderTemplate(rec, tmpl, map[string]string{"Name": "=> /evil"}); err != nil {
+		t.Fatalf("could not render: %v", err)
+	}
+
+	if rec.status != Success || rec.meta != DefaultMIME {
+		t.Errorf("unexpected header '%d %s'", rec.status, rec.meta)
+	}
+
+	if got, want := rec.body.String(), "# Hello\n"+gemtext.Escape("=> /evil")+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	rec = &recorder{}
+	failing := gemtext.Must(gemtext.New("page").Parse("{{.Missing}}"))
+
+	if err := RenderTemplate(rec, failing, struct{}{}); err == nil {
+		t.Errorf("expected an error")
+	}
+
+	if rec.status != TemporaryFailure || rec.body.Len() != 0 {
+		t.Errorf("unexpected response '%d %s' %q", rec.status, rec.meta, rec.body.String())
+	}
+}
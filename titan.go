@@ -0,0 +1,148 @@
+package libgemini
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	titanParamSize  = "size"
+	titanParamMIME  = "mime"
+	titanParamToken = "token"
+	titanParamDelim = ";"
+)
+
+// TitanUpload holds the parameters and body of a titan:// request.
+//
+// Body reads exactly Size bytes from the connection, returning
+// io.ErrUnexpectedEOF if the client sends less.
+type TitanUpload struct {
+	Size  int64
+	MIME  string
+	Token string
+	Body  io.Reader
+}
+
+// Titan returns the upload of a titan:// request, or nil for other requests.
+func (r Request) Titan() *TitanUpload {
+	return r.titan
+}
+
+// parseTitanParams removes the ;key=value parameters from the path of a
+// titan:// URL, returning them as an upload without a body.
+func parseTitanParams(u *url.URL) (*TitanUpload, error) {
+	escapedPath, rawParams, _ := strings.Cut(u.EscapedPath(), titanParamDelim)
+
+	upath, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid path: %w", ErrInvalidRequest, err)
+	}
+
+	upload := &TitanUpload{Size: -1, MIME: DefaultMIME}
+
+	for _, param := range strings.Split(rawParams, titanParamDelim) {
+		key, rawVal, _ := strings.Cut(param, "=")
+
+		val, err := url.PathUnescape(rawVal)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid titan parameter '%s': %w", ErrInvalidRequest, key, err)
+		}
+
+		switch key {
+		case titanParamSize:
+			size, err := strconv.ParseInt(val, 10, 64)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("%w: invalid titan size '%s'", ErrInvalidRequest, val)
+			}
+
+			upload.Size = size
+		case titanParamMIME:
+			upload.MIME = val
+		case titanParamToken:
+			upload.Token = val
+		}
+	}
+
+	if upload.Size < 0 {
+		return nil, fmt.Errorf("%w: missing titan size", ErrInvalidRequest)
+	}
+
+	u.Path = upath
+	u.RawPath = ""
+
+	return upload, nil
+}
+
+// exactReader reads exactly n bytes from r.
+type exactReader struct {
+	r io.Reader
+	n int64
+}
+
+func (er *exactReader) Read(p []byte) (int, error) {
+	if er.n <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > er.n {
+		p = p[:er.n]
+	}
+
+	n, err := er.r.Read(p)
+	er.n -= int64(n)
+
+	if errors.Is(err, io.EOF) && er.n > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	return n, err //nolint:wrapcheck
+}
+
+// TitanHandler handles titan:// uploads.
+type TitanHandler interface {
+	ServeTitan(w ResponseWriter, r *Request, upload *TitanUpload)
+}
+
+// TitanHandlerFunc is an adapter allowing ordinary functions to be used as
+// titan handlers.
+type TitanHandlerFunc func(w ResponseWriter, r *Request, upload *TitanUpload)
+
+// ServeTitan calls fn(w, r, upload).
+func (fn TitanHandlerFunc) ServeTitan(w ResponseWriter, r *Request, upload *TitanUpload) {
+	fn(w, r, upload)
+}
+
+// HandleTitan returns a handler passing titan:// uploads of up to maxSize
+// bytes to h. Larger uploads are refused with PermanentFailure before
+// their body is read, and other requests are answered with BadRequest.
+func HandleTitan(maxSize int64, h TitanHandler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		upload := r.Titan()
+		if upload == nil {
+			Error(w, BadRequest, "titan request expected")
+
+			return
+		}
+
+		if upload.Size > maxSize {
+			Error(w, PermanentFailure, fmt.Sprintf("upload too large, maximum is %d bytes", maxSize))
+
+			return
+		}
+
+		h.ServeTitan(w, r, upload)
+	})
+}
+
+// RedirectToGemini redirects a titan:// request to the gemini:// URL of the
+// uploaded resource. It is meant to be called once an upload succeeded.
+func RedirectToGemini(w ResponseWriter, r *Request) {
+	u := r.URL()
+	u.Scheme = geminiScheme
+
+	Redirect(w, u.String(), RedirectTemporary)
+}
@@ -0,0 +1,161 @@
+package libgemini
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// rawTitan sends a titan request followed by body, returning the response.
+func rawTitan(t *testing.T, addr, rawURL, body string) Response {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, rawURL+CRLF+body); err != nil {
+		t.Fatalf("could not write request: %v", err)
+	}
+
+	if err := conn.CloseWrite(); err != nil {
+		t.Fatalf("could not close write: %v", err)
+	}
+
+	resp, err := ReadResponse(bufio.NewReader(conn))
+	if err != nil {
+		t.Fatalf("could not read response: %v", err)
+	}
+
+	return resp
+}
+
+func TestReadRequestTitan(t *testing.T) {
+	cases := []struct {
+		raw   string
+		path  string
+		size  int64
+		mime  string
+		token string
+		err   bool
+	}{
+		{"titan://example.com/a.gmi;size=5", "/a.gmi", 5, DefaultMIME, "", false},
+		{"titan://example.com/a.txt;mime=text/plain;size=0;token=s%3Bcret", "/a.txt", 0, "text/plain", "s;cret", false},
+		{"titan://example.com/a%20b.gmi;size=1", "/a b.gmi", 1, DefaultMIME, "", false},
+		{"titan://example.com/a.gmi", "", 0, "", "", true},
+		{"titan://example.com/a.gmi;size=-1", "", 0, "", "", true},
+		{"titan://example.com/a.gmi;size=big", "", 0, "", "", true},
+	}
+
+	for _, c := range cases {
+		req, err := ReadRequest(bufio.NewReader(strings.NewReader(c.raw + CRLF)))
+		if c.err {
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("%s: expected ErrInvalidRequest, got %v", c.raw, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.raw, err)
+
+			continue
+		}
+
+		upload := req.Titan()
+		if upload == nil {
+			t.Errorf("%s: expected an upload", c.raw)
+
+			continue
+		}
+
+		if req.URL().Path != c.path || upload.Size != c.size || upload.MIME != c.mime || upload.Token != c.token {
+			t.Errorf("%s: got path '%s', upload %+v", c.raw, req.URL().Path, upload)
+		}
+	}
+
+	req, err := ReadRequest(bufio.NewReader(strings.NewReader("gemini://example.com/a.gmi;size=5" + CRLF)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if req.Titan() != nil || req.URL().Path != "/a.gmi;size=5" {
+		t.Errorf("gemini requests must not be parsed as uploads")
+	}
+}
+
+func TestExactReader(t *testing.T) {
+	body, err := io.ReadAll(&exactReader{r: strings.NewReader("hello world"), n: 5})
+	if err != nil || string(body) != "hello" {
+		t.Errorf("got '%s', %v", body, err)
+	}
+
+	if _, err := io.ReadAll(&exactReader{r: strings.NewReader("hi"), n: 5}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestHandleTitan(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		uploaded = make(map[string]string)
+	)
+
+	h := HandleTitan(10, TitanHandlerFunc(func(w ResponseWriter, r *Request, upload *TitanUpload) {
+		if upload.Token != "secret" {
+			Error(w, CertificateNotAuthorized, "invalid token")
+
+			return
+		}
+
+		body, err := io.ReadAll(upload.Body)
+		if err != nil {
+			Error(w, BadRequest, "incomplete upload")
+
+			return
+		}
+
+		mu.Lock()
+		uploaded[r.URL().Path] = upload.MIME + " " + string(body)
+		mu.Unlock()
+
+		RedirectToGemini(w, r)
+	}))
+
+	addr := startTestServer(t, &Server{Handler: h})
+
+	cases := []struct {
+		url    string
+		body   string
+		status StatusCode
+		meta   string
+	}{
+		{"titan://localhost/a.txt;size=5;mime=text/plain;token=secret", "hello", RedirectTemporary, "gemini://localhost/a.txt"},
+		{"titan://localhost/b.gmi;size=11;token=secret", "hello world", PermanentFailure, "upload too large, maximum is 10 bytes"},
+		{"titan://localhost/c.gmi;size=5;token=wrong", "hello", CertificateNotAuthorized, "invalid token"},
+		{"titan://localhost/d.gmi;size=8;token=secret", "short", BadRequest, "incomplete upload"},
+		{"titan://localhost/e.gmi;token=secret", "", BadRequest, "bad request"},
+		{"gemini://localhost/a.txt", "", BadRequest, "titan request expected"},
+	}
+
+	for _, c := range cases {
+		resp := rawTitan(t, addr, c.url, c.body)
+		if resp.Header.Status != c.status || resp.Header.Meta != c.meta {
+			t.Errorf("%s: got '%d %s', want '%d %s'", c.url, resp.Header.Status, resp.Header.Meta, c.status, c.meta)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(uploaded) != 1 || uploaded["/a.txt"] != "text/plain hello" {
+		t.Errorf("unexpected uploads: %v", uploaded)
+	}
+}
@@ -0,0 +1,63 @@
+package libgemini
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+
+	"github.com/aalbacetef/tofu"
+)
+
+// Transport performs a single Gemini exchange on behalf of a Client.
+type Transport interface {
+	RoundTrip(ctx context.Context, req Request) (Response, error)
+}
+
+// netTransport performs exchanges over the network.
+type netTransport struct {
+	options Options
+	cfg     *tls.Config
+}
+
+func (t *netTransport) RoundTrip(ctx context.Context, req Request) (Response, error) {
+	conn, err := connect(ctx, t.options, t.cfg, req)
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+
+	resp, err := ReadResponse(conn)
+	if err != nil {
+		return resp, err
+	}
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if peerCerts := tlsConn.ConnectionState().PeerCertificates; len(peerCerts) > 0 {
+			resp.fingerprint = tofu.Fingerprint(peerCerts[0])
+		}
+	}
+
+	return resp, nil
+}
+
+func (c *Client) streamFromTransport(ctx context.Context, req Request) (*StreamResponse, error) {
+	options, _ := c.snapshot()
+
+	resp, err := c.Transport.RoundTrip(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := logHeaders(ctx, options, req, resp.Header); err != nil {
+		return nil, err
+	}
+
+	streamResp := &StreamResponse{
+		Header: resp.Header,
+		Body:   io.NopCloser(bytes.NewReader(resp.Content)),
+		req:    req,
+	}
+
+	return streamResp, nil
+}
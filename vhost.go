@@ -0,0 +1,182 @@
+package libgemini
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var ErrUnknownHost = errors.New("unknown host")
+
+const (
+	CertFileExt = ".crt"
+	KeyFileExt  = ".key"
+)
+
+// VirtualHosts serves several capsules from a single server, selecting the
+// certificate during the TLS handshake and the handler for each request by
+// hostname. Hostnames may be wildcards, e.g. "*.example.com", matching a
+// single level of subdomains.
+//
+// Requests whose URL host does not match the SNI host sent during the
+// handshake, or for hosts that are not served, are answered with
+// ProxyRequestRefused.
+//
+// Use TLSConfig to configure a Server with it:
+//
+//	srv := &libgemini.Server{Handler: vh, TLSConfig: vh.TLSConfig()}
+type VirtualHosts struct {
+	mu          sync.RWMutex
+	hosts       map[string]*virtualHost
+	defaultHost string
+}
+
+type virtualHost struct {
+	cert    *tls.Certificate
+	handler Handler
+}
+
+// NewVirtualHosts allocates and returns an empty VirtualHosts.
+func NewVirtualHosts() *VirtualHosts {
+	return &VirtualHosts{hosts: make(map[string]*virtualHost)}
+}
+
+// Add registers the certificate and handler for hostname, replacing any
+// previous registration. The first host added is used for clients that do
+// not send SNI.
+func (vh *VirtualHosts) Add(hostname string, cert tls.Certificate, handler Handler) {
+	hostname = strings.ToLower(hostname)
+
+	vh.mu.Lock()
+	defer vh.mu.Unlock()
+
+	if vh.defaultHost == "" {
+		vh.defaultHost = hostname
+	}
+
+	vh.hosts[hostname] = &virtualHost{cert: &cert, handler: handler}
+}
+
+// AddFromDir works like Add, loading the certificate from the files
+// <hostname>.crt and <hostname>.key in dir.
+func (vh *VirtualHosts) AddFromDir(dir, hostname string, handler Handler) error {
+	cert, err := loadHostCertificate(dir, hostname)
+	if err != nil {
+		return err
+	}
+
+	vh.Add(hostname, cert, handler)
+
+	return nil
+}
+
+func loadHostCertificate(dir, hostname string) (tls.Certificate, error) {
+	certFile, keyFile := hostCertificatePaths(dir, hostname)
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("could not load certificate for '%s': %w", hostname, err)
+	}
+
+	return cert, nil
+}
+
+// hostCertificatePaths returns the paths of the certificate and key files
+// for hostname inside dir. Wildcards are stored with a "_" in place of "*".
+func hostCertificatePaths(dir, hostname string) (string, string) {
+	base := filepath.Join(dir, strings.ReplaceAll(strings.ToLower(hostname), "*", "_"))
+
+	return base + CertFileExt, base + KeyFileExt
+}
+
+// LoadVirtualHosts builds a VirtualHosts from every <hostname>.crt and
+// <hostname>.key pair found in dir, using handlerFor to get the handler
+// of each host.
+func LoadVirtualHosts(dir string, handlerFor func(hostname string) Handler) (*VirtualHosts, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory '%s': %w", dir, err)
+	}
+
+	vh := NewVirtualHosts()
+
+	for _, entry := range entries {
+		hostname, isCert := strings.CutSuffix(entry.Name(), CertFileExt)
+		if entry.IsDir() || !isCert {
+			continue
+		}
+
+		hostname = strings.ReplaceAll(hostname, "_", "*")
+
+		if err := vh.AddFromDir(dir, hostname, handlerFor(hostname)); err != nil {
+			return nil, err
+		}
+	}
+
+	return vh, nil
+}
+
+func (vh *VirtualHosts) lookup(hostname string) (*virtualHost, bool) {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+
+	vh.mu.RLock()
+	defer vh.mu.RUnlock()
+
+	if hostname == "" {
+		hostname = vh.defaultHost
+	}
+
+	if host, found := vh.hosts[hostname]; found {
+		return host, true
+	}
+
+	if _, parent, found := strings.Cut(hostname, "."); found {
+		host, found := vh.hosts["*."+parent]
+
+		return host, found
+	}
+
+	return nil, false
+}
+
+// GetCertificate selects the certificate matching the SNI host. It is
+// meant to be used as tls.Config.GetCertificate.
+func (vh *VirtualHosts) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host, found := vh.lookup(hello.ServerName)
+	if !found {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownHost, hello.ServerName)
+	}
+
+	return host.cert, nil
+}
+
+// TLSConfig returns a tls.Config using GetCertificate.
+func (vh *VirtualHosts) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     minTLSVersion,
+		GetCertificate: vh.GetCertificate,
+	}
+}
+
+func (vh *VirtualHosts) ServeGemini(w ResponseWriter, r *Request) {
+	hostname := strings.ToLower(r.u.Hostname())
+
+	if r.TLS != nil && r.TLS.ServerName != "" && !strings.EqualFold(r.TLS.ServerName, hostname) {
+		Error(w, ProxyRequestRefused, "host does not match SNI")
+
+		return
+	}
+
+	host, found := vh.lookup(hostname)
+	if !found {
+		Error(w, ProxyRequestRefused, "host not served")
+
+		return
+	}
+
+	host.handler.ServeGemini(w, r)
+}
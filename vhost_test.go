@@ -0,0 +1,67 @@
+package libgemini
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"testing"
+)
+
+// rawGet sends rawURL over a TLS connection using sni, returning the
+// response and the certificate presented by the server.
+func rawGet(t *testing.T, addr, sni, rawURL string) (Response, string) {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: sni, InsecureSkipVerify: true}) //nolint:gosec
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, rawURL+CRLF); err != nil {
+		t.Fatalf("could not write request: %v", err)
+	}
+
+	resp, err := ReadResponse(bufio.NewReader(conn))
+	if err != nil {
+		t.Fatalf("could not read response: %v", err)
+	}
+
+	return resp, conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestVirtualHosts(t *testing.T) {
+	vh := NewVirtualHosts()
+
+	for _, host := range []string{"a.test", "*.b.test"} {
+		name := host
+		vh.Add(host, testCertificate(t, host), HandlerFunc(func(w ResponseWriter, _ *Request) {
+			w.Write([]byte(name))
+		}))
+	}
+
+	addr := startTestServer(t, &Server{Handler: vh, TLSConfig: vh.TLSConfig()})
+
+	cases := []struct {
+		sni    string
+		url    string
+		cert   string
+		status StatusCode
+		body   string
+	}{
+		{"a.test", "gemini://a.test/", "a.test", Success, "a.test"},
+		{"www.b.test", "gemini://www.b.test/", "*.b.test", Success, "*.b.test"},
+		{"a.test", "gemini://www.b.test/", "a.test", ProxyRequestRefused, ""},
+		{"a.test", "gemini://other.test/", "a.test", ProxyRequestRefused, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.sni+" "+c.url, func(tt *testing.T) {
+			resp, cert := rawGet(tt, addr, c.sni, c.url)
+
+			if cert != c.cert || resp.Header.Status != c.status || string(resp.Content) != c.body {
+				tt.Fatalf("got (%s, %d, %q), want (%s, %d, %q)", cert, resp.Header.Status, resp.Content, c.cert, c.status, c.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package libgemini
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// modTimeWatch tracks the modification times of the files backing a
+// reloadable resource, to reload it when they change.
+type modTimeWatch struct {
+	files []string
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// reload calls load and records the most recent modification time of the
+// files. The time is read before loading, so that files changed while
+// loading are reloaded by the next poll.
+func (mw *modTimeWatch) reload(load func() error) error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	modTime, err := latestModTime(mw.files)
+	if err != nil {
+		return err
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	mw.modTime = modTime
+
+	return nil
+}
+
+func (mw *modTimeWatch) changed() (bool, error) {
+	modTime, err := latestModTime(mw.files)
+	if err != nil {
+		return false, err
+	}
+
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	return !modTime.Equal(mw.modTime), nil
+}
+
+// watch polls the modification times every interval, calling reload when
+// they change, until ctx is done. Errors are logged, naming the resource
+// as what.
+func (mw *modTimeWatch) watch(ctx context.Context, interval time.Duration, logger *slog.Logger, what string, reload func() error) {
+	logger = loggerOrNoop(logger)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := mw.changed()
+		if err != nil {
+			logger.Error("could not check "+what, what, mw.files[0], "error", err)
+
+			continue
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err := reload(); err != nil {
+			logger.Error("could not reload "+what, what, mw.files[0], "error", err)
+		}
+	}
+}
+
+// latestModTime returns the most recent modification time of files.
+func latestModTime(files []string) (time.Time, error) {
+	latest := time.Time{}
+
+	for _, fpath := range files {
+		info, err := os.Stat(fpath)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not stat '%s': %w", fpath, err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}